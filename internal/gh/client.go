@@ -1,9 +1,12 @@
 package gh
 
 import (
+	"errors"
 	"fmt"
-	"sort"
-	"sync"
+	"iter"
+	"net/url"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
@@ -47,97 +50,108 @@ func NewClient(repoOverride string) (GHClient, error) {
 	}, nil
 }
 
-func (c *Client) FetchWorkflowRuns(branch string, status string, since time.Time, limit int) ([]WorkflowRun, error) {
-	// First fetch page 1 to get TotalCount
-	firstPage, totalCount, err := c.fetchWorkflowRunsPage(branch, status, 1, 100)
-	if err != nil {
-		return nil, err
-	}
-
-	allRuns := firstPage
-	if !since.IsZero() {
-		// Filter first page
-		var filtered []WorkflowRun
-		for _, run := range allRuns {
-			if run.CreatedAt.After(since) {
-				filtered = append(filtered, run)
-			}
-		}
-		allRuns = filtered
-		// If we already hit runs older than since, or we have enough runs for the limit, return early
-		if (len(firstPage) > 0 && firstPage[len(firstPage)-1].CreatedAt.Before(since)) || (limit > 0 && len(allRuns) >= limit) {
-			if limit > 0 && len(allRuns) > limit {
-				allRuns = allRuns[:limit]
+// maxRunPages is a safety net against paging forever; at 100 runs per page it
+// is far beyond what any realistic --since window needs.
+const maxRunPages = 100
+
+// ErrTruncated is yielded by WorkflowRuns when pagination stopped before the
+// end of the result set, either at maxRunPages or because the API refused to
+// return more (it serves at most 1,000 results for filtered queries).
+var ErrTruncated = errors.New("results truncated")
+
+// WorkflowRuns iterates over the runs matching filter, newest first. Pages are
+// fetched lazily as the caller consumes runs, so breaking out of the loop at a
+// since boundary or limit avoids requesting pages nobody looks at.
+func (c *Client) WorkflowRuns(filter RunFilter) iter.Seq2[WorkflowRun, error] {
+	return func(yield func(WorkflowRun, error) bool) {
+		perPage := 100
+		fetched := 0
+
+		for page := 1; ; page++ {
+			if page > maxRunPages {
+				yield(WorkflowRun{}, fmt.Errorf("%w: stopped after %d pages (%d runs)", ErrTruncated, maxRunPages, fetched))
+				return
 			}
-			return allRuns, nil
-		}
-	}
 
-	remainingPages := (totalCount + 99) / 100
-	if remainingPages > 10 {
-		remainingPages = 10 // Cap at 10 pages
-	}
-
-	if remainingPages <= 1 {
-		if limit > 0 && len(allRuns) > limit {
-			allRuns = allRuns[:limit]
-		}
-		return allRuns, nil
-	}
-
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	errChan := make(chan error, remainingPages-1)
-
-	for p := 2; p <= remainingPages; p++ {
-		wg.Add(1)
-		go func(page int) {
-			defer wg.Done()
-			runs, _, err := c.fetchWorkflowRunsPage(branch, status, page, 100)
+			runs, totalCount, err := c.fetchWorkflowRunsPage(filter, page, perPage)
 			if err != nil {
-				errChan <- err
+				yield(WorkflowRun{}, err)
 				return
 			}
 
-			var filtered []WorkflowRun
 			for _, run := range runs {
-				if !since.IsZero() && run.CreatedAt.Before(since) {
-					continue
+				if !yield(run, nil) {
+					return
 				}
-				filtered = append(filtered, run)
 			}
+			fetched += len(runs)
 
-			mu.Lock()
-			allRuns = append(allRuns, filtered...)
-			mu.Unlock()
-		}(p)
-	}
-
-	wg.Wait()
-	close(errChan)
-	for err := range errChan {
-		if err != nil {
-			return nil, err
+			if fetched >= totalCount {
+				return
+			}
+			if len(runs) < perPage {
+				yield(WorkflowRun{}, fmt.Errorf("%w: the API returned %d of %d runs", ErrTruncated, fetched, totalCount))
+				return
+			}
 		}
 	}
+}
 
-	if limit > 0 && len(allRuns) > limit {
-		sort.Slice(allRuns, func(i, j int) bool {
-			return allRuns[i].CreatedAt.After(allRuns[j].CreatedAt)
-		})
-		allRuns = allRuns[:limit]
+// runsPath builds the list endpoint for a filter, using the per-workflow
+// endpoint when a workflow is given.
+func (c *Client) runsPath(filter RunFilter, perPage int, page int) string {
+	path := fmt.Sprintf("repos/%s/%s/actions/runs", c.repo.Owner, c.repo.Name)
+	if filter.Workflow != "" {
+		path = fmt.Sprintf("repos/%s/%s/actions/workflows/%s/runs", c.repo.Owner, c.repo.Name, url.PathEscape(filter.Workflow))
 	}
 
-	return allRuns, nil
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(perPage))
+	query.Set("page", strconv.Itoa(page))
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.Branch != "" {
+		query.Set("branch", filter.Branch)
+	}
+	if filter.Event != "" {
+		query.Set("event", filter.Event)
+	}
+	if filter.HeadSha != "" {
+		query.Set("head_sha", filter.HeadSha)
+	}
+	if created := createdQuery(filter.CreatedAfter, filter.CreatedBefore); created != "" {
+		query.Set("created", created)
+	}
+	return path + "?" + query.Encode()
 }
 
-func (c *Client) fetchWorkflowRunsPage(branch string, status string, page int, perPage int) ([]WorkflowRun, int, error) {
-	path := fmt.Sprintf("repos/%s/%s/actions/runs?status=%s&per_page=%d&page=%d", c.repo.Owner, c.repo.Name, status, perPage, page)
-	if branch != "" {
-		path += fmt.Sprintf("&branch=%s", branch)
+// createdQuery renders a created-at window in GitHub's search syntax:
+// "A..B", ">=A" or "<=B".
+func createdQuery(after, before time.Time) string {
+	const layout = "2006-01-02T15:04:05Z"
+	switch {
+	case !after.IsZero() && !before.IsZero():
+		return after.UTC().Format(layout) + ".." + before.UTC().Format(layout)
+	case !after.IsZero():
+		return ">=" + after.UTC().Format(layout)
+	case !before.IsZero():
+		return "<=" + before.UTC().Format(layout)
 	}
+	return ""
+}
+
+func (c *Client) fetchWorkflowRunsPage(filter RunFilter, page int, perPage int) ([]WorkflowRun, int, error) {
+	path := c.runsPath(filter, perPage, page)
 
-	fmt.Printf("Fetching page %d for runs with status %s...\n", page, status)
+	switch {
+	case filter.HeadSha != "":
+		fmt.Printf("Fetching page %d for runs with SHA %s and status %s...\n", page, filter.HeadSha, filter.Status)
+	case filter.Workflow != "":
+		fmt.Printf("Fetching page %d for %s runs with status %s...\n", page, filter.Workflow, filter.Status)
+	default:
+		fmt.Printf("Fetching page %d for runs with status %s...\n", page, filter.Status)
+	}
 	var response WorkflowRunsResponse
 	err := c.restClient.Get(path, &response)
 	if err != nil {
@@ -145,57 +159,68 @@ func (c *Client) fetchWorkflowRunsPage(branch string, status string, page int, p
 	}
 	return response.WorkflowRuns, response.TotalCount, nil
 }
-func (c *Client) FetchWorkflowRunsForSha(sha string, status string, limit int) ([]WorkflowRun, error) {
-	var allRuns []WorkflowRun
-	page := 1
-	perPage := 100
 
-	for {
-		path := fmt.Sprintf("repos/%s/%s/actions/runs?head_sha=%s&per_page=%d&page=%d", c.repo.Owner, c.repo.Name, sha, perPage, page)
-		if status != "" {
-			path += fmt.Sprintf("&status=%s", status)
-		}
+func (c *Client) FetchWorkflowRun(runID int64) (*WorkflowRun, error) {
+	path := fmt.Sprintf("repos/%s/%s/actions/runs/%d", c.repo.Owner, c.repo.Name, runID)
 
-		fmt.Printf("Fetching page %d for runs with SHA %s and status %s...\n", page, sha, status)
-		var response WorkflowRunsResponse
-		err := c.restClient.Get(path, &response)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(response.WorkflowRuns) == 0 {
-			break
-		}
-
-		for _, run := range response.WorkflowRuns {
-			allRuns = append(allRuns, run)
-			if limit > 0 && len(allRuns) >= limit {
-				return allRuns, nil
-			}
-		}
+	var run WorkflowRun
+	err := c.restClient.Get(path, &run)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
 
-		if len(allRuns) >= response.TotalCount || len(response.WorkflowRuns) < perPage {
-			break
+// pullRequestFields is the selection shared by the pull request queries; it
+// must stay in sync with pullRequestNode.
+const pullRequestFields = `
+	number
+	headRefOid
+	isDraft
+	title
+	baseRefName
+	reviewDecision
+	author {
+		login
+	}
+	labels(first: 50) {
+		nodes {
+			name
 		}
-		if page > 5 {
-			break
+	}
+	mergeQueueEntry {
+		state
+		position
+		headCommit {
+			oid
 		}
-		page++
 	}
+`
+
+// pullRequestNode is the GraphQL shape of a pull request; connections such as
+// labels are flattened by toPullRequest.
+type pullRequestNode struct {
+	PullRequest
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+}
 
-	return allRuns, nil
+func (n pullRequestNode) toPullRequest() PullRequest {
+	pr := n.PullRequest
+	for _, l := range n.Labels.Nodes {
+		pr.Labels = append(pr.Labels, l.Name)
+	}
+	return pr
 }
 
 func (c *Client) FetchPullRequest(number int) (*PullRequest, error) {
 	query := `
 		query GetPR($owner: String!, $name: String!, $number: Int!) {
 			repository(owner: $owner, name: $name) {
-				pullRequest(number: $number) {
-					number
-					headRefOid
-					isDraft
-					title
-				}
+				pullRequest(number: $number) {` + pullRequestFields + `}
 			}
 		}
 	`
@@ -208,7 +233,7 @@ func (c *Client) FetchPullRequest(number int) (*PullRequest, error) {
 
 	var response struct {
 		Repository struct {
-			PullRequest PullRequest `json:"pullRequest"`
+			PullRequest pullRequestNode `json:"pullRequest"`
 		} `json:"repository"`
 	}
 
@@ -217,107 +242,200 @@ func (c *Client) FetchPullRequest(number int) (*PullRequest, error) {
 		return nil, err
 	}
 
-	return &response.Repository.PullRequest, nil
+	pr := response.Repository.PullRequest.toPullRequest()
+	return &pr, nil
 }
 
-func (c *Client) FetchOpenPullRequests() ([]PullRequest, error) {
+// OpenPullRequests iterates over all open pull requests, newest first,
+// following the GraphQL cursor one page at a time as the caller consumes them.
+// Iteration stops after the first error, which is yielded to the caller.
+func (c *Client) OpenPullRequests() iter.Seq2[PullRequest, error] {
 	query := `
-		query ListPRs($owner: String!, $name: String!) {
+		query ListPRs($owner: String!, $name: String!, $cursor: String) {
 			repository(owner: $owner, name: $name) {
-				pullRequests(first: 100, states: OPEN, orderBy: {field: CREATED_AT, direction: DESC}) {
-					nodes {
-						number
-						headRefOid
-						isDraft
-						title
+				pullRequests(first: 100, after: $cursor, states: OPEN, orderBy: {field: CREATED_AT, direction: DESC}) {
+					nodes {` + pullRequestFields + `}
+					pageInfo {
+						hasNextPage
+						endCursor
 					}
 				}
 			}
 		}
 	`
 
-	variables := map[string]interface{}{
-		"owner": c.repo.Owner,
-		"name":  c.repo.Name,
-	}
+	return func(yield func(PullRequest, error) bool) {
+		var cursor *string
+		for page := 1; ; page++ {
+			variables := map[string]interface{}{
+				"owner":  c.repo.Owner,
+				"name":   c.repo.Name,
+				"cursor": cursor,
+			}
 
-	var response struct {
-		Repository struct {
-			PullRequests struct {
-				Nodes []PullRequest `json:"nodes"`
-			} `json:"pullRequests"`
-		} `json:"repository"`
-	}
+			var response struct {
+				Repository struct {
+					PullRequests struct {
+						Nodes    []pullRequestNode `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"pullRequests"`
+				} `json:"repository"`
+			}
 
-	err := c.graphqlClient.Do(query, variables, &response)
-	if err != nil {
-		return nil, err
+			fmt.Printf("Fetching page %d of open PRs...\n", page)
+			err := c.graphqlClient.Do(query, variables, &response)
+			if err != nil {
+				yield(PullRequest{}, err)
+				return
+			}
+
+			for _, n := range response.Repository.PullRequests.Nodes {
+				if !yield(n.toPullRequest(), nil) {
+					return
+				}
+			}
+
+			pageInfo := response.Repository.PullRequests.PageInfo
+			if !pageInfo.HasNextPage {
+				return
+			}
+			cursor = &pageInfo.EndCursor
+		}
 	}
+}
 
-	return response.Repository.PullRequests.Nodes, nil
+type commitResponse struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
 }
 
-func (c *Client) FetchCommits(branch string, limit int) ([]Commit, error) {
-	path := fmt.Sprintf("repos/%s/%s/commits?per_page=%d", c.repo.Owner, c.repo.Name, limit)
-	if branch != "" {
-		path += fmt.Sprintf("&sha=%s", branch)
+func (r commitResponse) toCommit() Commit {
+	return Commit{
+		SHA:     r.SHA,
+		Message: r.Commit.Message,
 	}
+}
 
-	var response []struct {
-		SHA    string `json:"sha"`
-		Commit struct {
-			Message string `json:"message"`
-		} `json:"commit"`
+func (c *Client) FetchCommits(branch string, limit int) ([]Commit, error) {
+	perPage := limit
+	if perPage <= 0 || perPage > 100 {
+		perPage = 100
 	}
 
-	err := c.restClient.Get(path, &response)
-	if err != nil {
-		return nil, err
+	var commits []Commit
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("repos/%s/%s/commits?per_page=%d&page=%d", c.repo.Owner, c.repo.Name, perPage, page)
+		if branch != "" {
+			path += fmt.Sprintf("&sha=%s", branch)
+		}
+
+		var response []commitResponse
+		err := c.restClient.Get(path, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range response {
+			commits = append(commits, r.toCommit())
+			if limit > 0 && len(commits) >= limit {
+				return commits, nil
+			}
+		}
+
+		if len(response) < perPage {
+			break
+		}
 	}
 
-	commits := make([]Commit, len(response))
-	for i, r := range response {
-		commits[i] = Commit{
-			SHA:     r.SHA,
-			Message: r.Commit.Message,
+	return commits, nil
+}
+
+// FetchCommitRange returns the commits reachable from head but not from base
+// (git's base..head), newest first. All pages of the compare endpoint are
+// followed, so long ranges are not truncated.
+func (c *Client) FetchCommitRange(base, head string) ([]Commit, error) {
+	perPage := 100
+
+	var commits []Commit
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("repos/%s/%s/compare/%s...%s?per_page=%d&page=%d",
+			c.repo.Owner, c.repo.Name, base, head, perPage, page)
+
+		fmt.Printf("Fetching page %d of commits in %s..%s...\n", page, ShortSha(base), ShortSha(head))
+		var response struct {
+			TotalCommits int              `json:"total_commits"`
+			Commits      []commitResponse `json:"commits"`
+		}
+		err := c.restClient.Get(path, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range response.Commits {
+			commits = append(commits, r.toCommit())
+		}
+
+		if len(response.Commits) < perPage || len(commits) >= response.TotalCommits {
+			break
 		}
 	}
 
+	// The compare endpoint lists oldest first; callers expect tip first.
+	slices.Reverse(commits)
 	return commits, nil
 }
 
+// ShortSha abbreviates a commit SHA to the 7 characters GitHub shows.
+func ShortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
 func (c *Client) FetchCommit(sha string) (*Commit, error) {
 	path := fmt.Sprintf("repos/%s/%s/commits/%s", c.repo.Owner, c.repo.Name, sha)
 
-	var response struct {
-		SHA    string `json:"sha"`
-		Commit struct {
-			Message string `json:"message"`
-		} `json:"commit"`
-	}
-
+	var response commitResponse
 	err := c.restClient.Get(path, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Commit{
-		SHA:     response.SHA,
-		Message: response.Commit.Message,
-	}, nil
+	commit := response.toCommit()
+	return &commit, nil
 }
 
+// FetchWorkflowRunJobs returns every job of the run's latest attempt. Large
+// matrices span several pages, so all of them are fetched.
 func (c *Client) FetchWorkflowRunJobs(runID int64) ([]WorkflowJob, error) {
-	path := fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs", c.repo.Owner, c.repo.Name, runID)
+	perPage := 100
 
-	var response struct {
-		Jobs []WorkflowJob `json:"jobs"`
-	}
-	err := c.restClient.Get(path, &response)
-	if err != nil {
-		return nil, err
+	var jobs []WorkflowJob
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs?filter=latest&per_page=%d&page=%d",
+			c.repo.Owner, c.repo.Name, runID, perPage, page)
+
+		var response struct {
+			TotalCount int           `json:"total_count"`
+			Jobs       []WorkflowJob `json:"jobs"`
+		}
+		err := c.restClient.Get(path, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, response.Jobs...)
+		if len(response.Jobs) < perPage || len(jobs) >= response.TotalCount {
+			break
+		}
 	}
-	return response.Jobs, nil
+	return jobs, nil
 }
 
 func (c *Client) RerunWorkflow(runID int64, failedOnly bool) error {
@@ -343,6 +461,15 @@ func (c *Client) GetRateLimit() (*RateLimit, error) {
 	return &response.Resources.Core, nil
 }
 
+func (c *Client) CurrentUser() (*User, error) {
+	var user User
+	err := c.restClient.Get("user", &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (c *Client) Repo() repository.Repository {
 	return c.repo
 }
@@ -0,0 +1,112 @@
+package gh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// jobsClient returns a Client whose REST calls are answered from a run with
+// total jobs, and records the requested paths.
+func jobsClient(t *testing.T, total int, paths *[]string) *Client {
+	t.Helper()
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		*paths = append(*paths, req.URL.RequestURI())
+		query := req.URL.Query()
+		if query.Get("filter") != "latest" {
+			t.Errorf("Expected filter=latest, got %s", req.URL.RequestURI())
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		perPage, _ := strconv.Atoi(query.Get("per_page"))
+
+		var jobs []WorkflowJob
+		for i := (page-1)*perPage + 1; i <= min(page*perPage, total); i++ {
+			jobs = append(jobs, WorkflowJob{ID: int64(i), Name: fmt.Sprintf("job %d", i), Conclusion: "success"})
+		}
+		body, err := json.Marshal(map[string]any{"total_count": total, "jobs": jobs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(string(body))),
+			Request:    req,
+		}, nil
+	})
+
+	rest, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", AuthToken: "token", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{restClient: rest, repo: repository.Repository{Host: "github.com", Owner: "owner", Name: "repo"}}
+}
+
+func TestClient_FetchWorkflowRunJobs(t *testing.T) {
+	tests := []struct {
+		total     int
+		wantPages int
+	}{
+		{total: 0, wantPages: 1},
+		{total: 30, wantPages: 1},
+		// A full last page must not cost an extra, empty request.
+		{total: 100, wantPages: 1},
+		{total: 250, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.total), func(t *testing.T) {
+			var paths []string
+			jobs, err := jobsClient(t, tt.total, &paths).FetchWorkflowRunJobs(7)
+			if err != nil {
+				t.Fatalf("FetchWorkflowRunJobs: %v", err)
+			}
+			if len(jobs) != tt.total {
+				t.Errorf("Expected %d jobs, got %d", tt.total, len(jobs))
+			}
+			if len(paths) != tt.wantPages {
+				t.Errorf("Expected %d requests, got %v", tt.wantPages, paths)
+			}
+		})
+	}
+}
+
+func TestWorkflowJob_Failed(t *testing.T) {
+	for conclusion, want := range map[string]bool{
+		"failure":   true,
+		"timed_out": true,
+		"cancelled": false,
+		"success":   false,
+		"skipped":   false,
+		"":          false,
+	} {
+		if got := (WorkflowJob{Conclusion: conclusion}).Failed(); got != want {
+			t.Errorf("Failed() for %q = %v, want %v", conclusion, got, want)
+		}
+	}
+
+	job := WorkflowJob{Steps: []WorkflowStep{
+		{Name: "Checkout", Conclusion: "success"},
+		{Name: "Test", Conclusion: "timed_out"},
+		{Name: "Upload", Conclusion: "failure"},
+	}}
+	if step, ok := job.FailedStep(); !ok || step.Name != "Test" {
+		t.Errorf("Expected the first failed step to be Test, got %+v", step)
+	}
+	if _, ok := (WorkflowJob{}).FailedStep(); ok {
+		t.Error("Expected no failed step for a job without steps")
+	}
+}
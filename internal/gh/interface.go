@@ -1,6 +1,7 @@
 package gh
 
 import (
+	"iter"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/repository"
@@ -15,8 +16,42 @@ type WorkflowRun struct {
 	HeadSha    string    `json:"head_sha"`
 	Conclusion string    `json:"conclusion"`
 	Status     string    `json:"status"`
+	Event      string    `json:"event"`
 	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 	HTMLURL    string    `json:"html_url"`
+	WorkflowID int64     `json:"workflow_id"`
+	Path       string    `json:"path"`
+
+	Actor           User `json:"actor"`
+	TriggeringActor User `json:"triggering_actor"`
+
+	HeadRepository RepoRef `json:"head_repository"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+// RepoRef identifies a repository by its OWNER/REPO name.
+type RepoRef struct {
+	FullName string `json:"full_name"`
+}
+
+// RunFilter narrows a workflow runs query on the server side. Workflow is a
+// numeric workflow ID or a workflow file name (e.g. "ci.yml"); when set, the
+// per-workflow runs endpoint is used. Event is the triggering event, e.g.
+// push, pull_request or schedule. HeadSha restricts the query to runs for a single commit.
+// CreatedAfter/CreatedBefore bound the run creation time; either may be zero.
+type RunFilter struct {
+	Branch   string
+	Status   string
+	Workflow string
+	Event    string
+	HeadSha  string
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
 }
 
 type WorkflowRunsResponse struct {
@@ -29,6 +64,26 @@ type PullRequest struct {
 	HeadRefOid string `json:"headRefOid"`
 	IsDraft    bool   `json:"isDraft"`
 	Title      string `json:"title"`
+	Author     User   `json:"author"`
+
+	BaseRefName    string   `json:"baseRefName"`
+	ReviewDecision string   `json:"reviewDecision"` // APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or empty
+	Labels         []string `json:"-"`
+
+	MergeQueueEntry *MergeQueueEntry `json:"mergeQueueEntry"`
+}
+
+// MergeQueueEntry describes a PR's place in the merge queue. Checks for a
+// queued PR run as merge_group events against HeadCommit on a temporary
+// gh-readonly-queue/... branch rather than against the PR head.
+type MergeQueueEntry struct {
+	State      string    `json:"state"`
+	Position   int       `json:"position"`
+	HeadCommit GitObject `json:"headCommit"`
+}
+
+type GitObject struct {
+	Oid string `json:"oid"`
 }
 
 type RateLimit struct {
@@ -43,20 +98,52 @@ type Commit struct {
 }
 
 type WorkflowJob struct {
-	ID         int64  `json:"id"`
+	ID          int64          `json:"id"`
+	RunID       int64          `json:"run_id"`
+	RunAttempt  int            `json:"run_attempt"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"`
+	Conclusion  string         `json:"conclusion"`
+	StartedAt   time.Time      `json:"started_at"`
+	CompletedAt time.Time      `json:"completed_at"`
+	RunnerName  string         `json:"runner_name"`
+	HTMLURL     string         `json:"html_url"`
+	Steps       []WorkflowStep `json:"steps"`
+}
+
+type WorkflowStep struct {
+	Number     int    `json:"number"`
 	Name       string `json:"name"`
+	Status     string `json:"status"`
 	Conclusion string `json:"conclusion"`
 }
 
+// Failed reports whether the job ended in a state that rerun-failed-jobs retries.
+func (j WorkflowJob) Failed() bool {
+	return j.Conclusion == "failure" || j.Conclusion == "timed_out"
+}
+
+// FailedStep returns the first step that failed, if any.
+func (j WorkflowJob) FailedStep() (WorkflowStep, bool) {
+	for _, step := range j.Steps {
+		if step.Conclusion == "failure" || step.Conclusion == "timed_out" {
+			return step, true
+		}
+	}
+	return WorkflowStep{}, false
+}
+
 type GHClient interface {
-	FetchWorkflowRuns(branch string, status string, since time.Time, limit int) ([]WorkflowRun, error)
-	FetchWorkflowRunsForSha(sha string, status string, limit int) ([]WorkflowRun, error)
+	WorkflowRuns(filter RunFilter) iter.Seq2[WorkflowRun, error]
+	FetchWorkflowRun(runID int64) (*WorkflowRun, error)
 	FetchPullRequest(number int) (*PullRequest, error)
-	FetchOpenPullRequests() ([]PullRequest, error)
+	OpenPullRequests() iter.Seq2[PullRequest, error]
 	FetchCommits(branch string, limit int) ([]Commit, error)
+	FetchCommitRange(base, head string) ([]Commit, error)
 	FetchCommit(sha string) (*Commit, error)
 	FetchWorkflowRunJobs(runID int64) ([]WorkflowJob, error)
 	RerunWorkflow(runID int64, failedOnly bool) error
 	GetRateLimit() (*RateLimit, error)
+	CurrentUser() (*User, error)
 	Repo() repository.Repository
 }
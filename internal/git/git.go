@@ -0,0 +1,88 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RevParse resolves a ref (short SHA, tag, HEAD~3, ...) to a full commit SHA
+// using the git checkout in the current working directory.
+func RevParse(ref string) (string, error) {
+	return run("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+}
+
+// IsBranch reports whether name is a local branch of the current checkout.
+func IsBranch(name string) bool {
+	_, err := run("show-ref", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+// Branch describes the checked-out branch and what it tracks. Upstream is the
+// branch name on Remote (branch.<name>.merge without refs/heads/) and is empty
+// when no upstream is configured.
+type Branch struct {
+	Name      string
+	Remote    string
+	RemoteURL string
+	Upstream  string
+}
+
+// CurrentBranch returns the checked-out branch and its upstream, if any. It
+// fails on a detached HEAD or outside a git checkout.
+func CurrentBranch() (*Branch, error) {
+	name, err := run("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("no branch checked out (detached HEAD?): %w", err)
+	}
+	b := &Branch{Name: name}
+
+	// A missing config key makes git exit 1; that just means no upstream.
+	remote, _ := run("config", "--get", "branch."+name+".remote")
+	merge, _ := run("config", "--get", "branch."+name+".merge")
+	if remote == "" || remote == "." || merge == "" {
+		return b, nil
+	}
+	b.Remote = remote
+	b.Upstream = strings.TrimPrefix(merge, "refs/heads/")
+	if u, err := RemoteURL(remote); err == nil {
+		b.RemoteURL = u
+	}
+	return b, nil
+}
+
+// RemoteURL returns the fetch URL of a remote.
+func RemoteURL(remote string) (string, error) {
+	return run("remote", "get-url", remote)
+}
+
+// RemoteURLs returns the fetch URLs of every configured remote.
+func RemoteURLs() ([]string, error) {
+	out, err := run("remote")
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for remote := range strings.FieldsSeq(out) {
+		if u, err := RemoteURL(remote); err == nil {
+			urls = append(urls, u)
+		}
+	}
+	return urls, nil
+}
+
+func run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,260 @@
+package rerunner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// rerunCommand is the comment prefix that triggers a rerun on issue_comment
+// events; other comments are ignored.
+const rerunCommand = "/rerun-failed"
+
+// trustedAssociations are the comment author associations allowed to trigger
+// reruns. On a public repository anyone can comment, and the workflow's token
+// has actions: write.
+var trustedAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// ActionsEvent is what --from-event infers from the GitHub Actions
+// environment: the repository, and what to rerun for the triggering event.
+// Skip is set, with the reason, when the event calls for no action.
+// MaxAttempts is the attempt cap the event needs when none is given.
+type ActionsEvent struct {
+	Name        string
+	Repo        string
+	Targets     []string
+	Branch      string
+	Since       string
+	Skip        string
+	MaxAttempts int
+}
+
+type actionsPayload struct {
+	Action      string     `json:"action"`
+	Repository  gh.RepoRef `json:"repository"`
+	WorkflowRun *struct {
+		ID         int64  `json:"id"`
+		RunAttempt int    `json:"run_attempt"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		HTMLURL    string `json:"html_url"`
+	} `json:"workflow_run"`
+	Issue *struct {
+		Number      int              `json:"number"`
+		PullRequest *json.RawMessage `json:"pull_request"`
+	} `json:"issue"`
+	Comment *struct {
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+		User              struct {
+			Login string `json:"login"`
+			Type  string `json:"type"`
+		} `json:"user"`
+	} `json:"comment"`
+}
+
+// ReadActionsEvent reads GITHUB_EVENT_NAME and the payload at
+// GITHUB_EVENT_PATH through getenv. Supported events are workflow_run (rerun
+// the completed run, capped at defaultMaxAttempts), schedule (scan the
+// scheduled branch, 24h back by default) and issue_comment (rerun the
+// commented PR when an owner, member or collaborator comments /rerun-failed).
+func ReadActionsEvent(getenv func(string) string) (*ActionsEvent, error) {
+	name, path := getenv("GITHUB_EVENT_NAME"), getenv("GITHUB_EVENT_PATH")
+	if name == "" || path == "" {
+		return nil, errors.New("--from-event needs GITHUB_EVENT_NAME and GITHUB_EVENT_PATH; is this running inside GitHub Actions?")
+	}
+
+	// On GitHub Enterprise Server, go-gh only reads the enterprise variables.
+	var host string
+	if u, err := url.Parse(getenv("GITHUB_SERVER_URL")); err == nil && u.Host != "" && u.Host != "github.com" {
+		host = u.Host
+	}
+	if host == "" && getenv("GITHUB_TOKEN") == "" && getenv("GH_TOKEN") == "" {
+		return nil, errors.New("--from-event needs a token: set GITHUB_TOKEN: ${{ github.token }} in the step's env")
+	}
+	if host != "" && getenv("GH_ENTERPRISE_TOKEN") == "" && getenv("GITHUB_ENTERPRISE_TOKEN") == "" {
+		return nil, fmt.Errorf("--from-event needs a token for %s: set GH_ENTERPRISE_TOKEN: ${{ github.token }} in the step's env", host)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading event payload: %w", err)
+	}
+	var payload actionsPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("parsing event payload %s: %w", path, err)
+	}
+
+	ev := &ActionsEvent{Name: name}
+	ev.Repo = payload.Repository.FullName
+	if ev.Repo == "" {
+		ev.Repo = getenv("GITHUB_REPOSITORY")
+	}
+	if ev.Repo == "" {
+		return nil, errors.New("could not infer the repository from the event payload or GITHUB_REPOSITORY")
+	}
+	if host != "" {
+		ev.Repo = host + "/" + ev.Repo
+	}
+
+	switch name {
+	case "workflow_run":
+		run := payload.WorkflowRun
+		switch {
+		case run == nil:
+			return nil, errors.New("workflow_run payload has no workflow_run")
+		case payload.Action != "completed" || run.Status != "completed":
+			ev.Skip = fmt.Sprintf("run %d is not completed yet (%s)", run.ID, payload.Action)
+		case run.Conclusion == "success":
+			ev.Skip = fmt.Sprintf("run %d succeeded", run.ID)
+		default:
+			target := run.HTMLURL
+			if target == "" {
+				target = fmt.Sprintf("https://github.com/%s/actions/runs/%d", payload.Repository.FullName, run.ID)
+			}
+			ev.Targets = []string{target}
+			// A rerun that fails triggers this workflow again.
+			ev.MaxAttempts = defaultMaxAttempts
+		}
+	case "schedule":
+		ev.Branch = getenv("GITHUB_REF_NAME")
+		ev.Since = "24h"
+	case "issue_comment":
+		switch {
+		case payload.Issue == nil || payload.Issue.PullRequest == nil:
+			ev.Skip = "comment is not on a pull request"
+		case payload.Action != "created":
+			ev.Skip = "comment was " + payload.Action
+		case payload.Comment == nil || !strings.HasPrefix(strings.TrimSpace(payload.Comment.Body), rerunCommand):
+			ev.Skip = "comment does not start with " + rerunCommand
+		case payload.Comment.User.Type == "Bot":
+			ev.Skip = "comment is by bot " + payload.Comment.User.Login
+		case !slices.Contains(trustedAssociations, payload.Comment.AuthorAssociation):
+			ev.Skip = fmt.Sprintf("commenter %s is not an owner, member or collaborator (%s)",
+				payload.Comment.User.Login, payload.Comment.AuthorAssociation)
+		default:
+			ev.Targets = []string{strconv.Itoa(payload.Issue.Number)}
+		}
+	default:
+		return nil, fmt.Errorf("--from-event does not support %s events (supported: workflow_run, schedule, issue_comment)", name)
+	}
+	return ev, nil
+}
+
+// Apply fills in what the event implies without overriding explicit flags.
+func (e *ActionsEvent) Apply(opts *Options) {
+	if opts.Repo == "" {
+		opts.Repo = e.Repo
+	}
+	if len(opts.Targets) == 0 && opts.PRNumber == 0 && opts.Commit == "" && opts.Range == "" && !opts.AllOpenPRs {
+		opts.Targets = e.Targets
+		if opts.Branch == "" && !opts.AllBranches {
+			opts.Branch = e.Branch
+		}
+	}
+	if opts.Since == "" {
+		opts.Since = e.Since
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = e.MaxAttempts
+	}
+}
+
+// RunForEvent performs a pass for ev and writes the outcome to the job summary
+// (GITHUB_STEP_SUMMARY) and step outputs (GITHUB_OUTPUT) when those are set.
+// The report is written even when the pass fails, then the error is returned.
+func (r *Rerunner) RunForEvent(ev *ActionsEvent, getenv func(string) string) error {
+	sum := &summary{}
+	var runErr error
+	if ev.Skip != "" {
+		fmt.Printf("Nothing to do for %s event: %s\n", ev.Name, ev.Skip)
+	} else if s, err := r.run(); err != nil {
+		runErr = err
+	} else {
+		sum = s
+	}
+
+	if path := getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, sum.markdown(ev, runErr)); err != nil {
+			fmt.Printf("Warning: could not write job summary: %v\n", err)
+		}
+	}
+	if path := getenv("GITHUB_OUTPUT"); path != "" {
+		if err := appendFile(path, sum.outputs()); err != nil {
+			fmt.Printf("Warning: could not write step outputs: %v\n", err)
+		}
+	}
+	return runErr
+}
+
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// markdown renders the summary as a job summary table.
+func (s *summary) markdown(ev *ActionsEvent, runErr error) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### gh rerun-failed\n\nTriggered by `%s` on %s.\n\n", ev.Name, ev.Repo)
+	switch {
+	case runErr != nil:
+		fmt.Fprintf(&b, "**Error:** %s\n\n", runErr)
+	case ev.Skip != "":
+		fmt.Fprintf(&b, "Nothing to do: %s.\n\n", ev.Skip)
+	}
+
+	row := func(result string, run gh.WorkflowRun, details string) {
+		fmt.Fprintf(&b, "| %s | [%s #%d](%s) | %d | %s | %s |\n",
+			result, run.Name, run.RunNumber, run.HTMLURL, run.RunAttempt, run.HeadBranch, details)
+	}
+	if len(s.triggered)+len(s.failed)+len(s.skipped)+len(s.exhausted) > 0 {
+		b.WriteString("| Result | Run | Attempt | Branch | Details |\n|---|---|---|---|---|\n")
+		for _, run := range s.triggered {
+			row("✅ rerun", run, "")
+		}
+		for _, run := range s.failed {
+			row("❌ rerun failed", run, "")
+		}
+		for _, sk := range s.skipped {
+			row("⏭️ skipped", sk.run, sk.reason)
+		}
+		for _, run := range s.exhausted {
+			row("⚠️ exhausted", run, "attempt cap reached")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "**Summary:** %d triggered, %d failed, %d skipped, %d exhausted\n",
+		len(s.triggered), len(s.failed), len(s.skipped), len(s.exhausted))
+	return b.String()
+}
+
+// outputs renders the summary as GITHUB_OUTPUT key=value lines.
+func (s *summary) outputs() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, len(s.triggered))
+	for i, run := range s.triggered {
+		ids[i] = strconv.FormatInt(run.ID, 10)
+	}
+	return fmt.Sprintf("triggered=%d\nfailed=%d\nskipped=%d\nexhausted=%d\nrerun-ids=%s\n",
+		len(s.triggered), len(s.failed), len(s.skipped), len(s.exhausted), strings.Join(ids, ","))
+}
@@ -0,0 +1,183 @@
+package rerunner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// actionsEnv writes payload to a temp event file and returns a getenv for a
+// GitHub Actions step triggered by event.
+func actionsEnv(t *testing.T, event, payload string) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "event.json")
+	if err := os.WriteFile(path, []byte(payload), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"GITHUB_EVENT_NAME":   event,
+		"GITHUB_EVENT_PATH":   path,
+		"GITHUB_REPOSITORY":   "owner/repo",
+		"GITHUB_SERVER_URL":   "https://github.com",
+		"GITHUB_REF_NAME":     "main",
+		"GITHUB_TOKEN":        "token",
+		"GITHUB_STEP_SUMMARY": filepath.Join(dir, "summary.md"),
+		"GITHUB_OUTPUT":       filepath.Join(dir, "output"),
+	}
+}
+
+func getenv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestReadActionsEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       string
+		payload     string
+		wantTargets []string
+		wantBranch  string
+		wantSkip    bool
+	}{
+		{
+			name:        "failed workflow_run",
+			event:       "workflow_run",
+			payload:     `{"action":"completed","repository":{"full_name":"owner/repo"},"workflow_run":{"id":111,"status":"completed","conclusion":"failure","html_url":"https://github.com/owner/repo/actions/runs/111"}}`,
+			wantTargets: []string{"https://github.com/owner/repo/actions/runs/111"},
+		},
+		{
+			name:     "successful workflow_run",
+			event:    "workflow_run",
+			payload:  `{"action":"completed","repository":{"full_name":"owner/repo"},"workflow_run":{"id":111,"status":"completed","conclusion":"success"}}`,
+			wantSkip: true,
+		},
+		{
+			name:       "schedule",
+			event:      "schedule",
+			payload:    `{"schedule":"0 3 * * *"}`,
+			wantBranch: "main",
+		},
+		{
+			name:        "comment on a PR",
+			event:       "issue_comment",
+			payload:     `{"action":"created","repository":{"full_name":"owner/repo"},"issue":{"number":42,"pull_request":{"url":"https://api.github.com/repos/owner/repo/pulls/42"}},"comment":{"body":"/rerun-failed please","author_association":"MEMBER","user":{"login":"octocat","type":"User"}}}`,
+			wantTargets: []string{"42"},
+		},
+		{
+			name:     "other comment on a PR",
+			event:    "issue_comment",
+			payload:  `{"action":"created","repository":{"full_name":"owner/repo"},"issue":{"number":42,"pull_request":{"url":"https://api.github.com/repos/owner/repo/pulls/42"}},"comment":{"body":"LGTM","author_association":"MEMBER","user":{"login":"octocat","type":"User"}}}`,
+			wantSkip: true,
+		},
+		{
+			name:     "comment by an outsider",
+			event:    "issue_comment",
+			payload:  `{"action":"created","repository":{"full_name":"owner/repo"},"issue":{"number":42,"pull_request":{"url":"https://api.github.com/repos/owner/repo/pulls/42"}},"comment":{"body":"/rerun-failed","author_association":"NONE","user":{"login":"stranger","type":"User"}}}`,
+			wantSkip: true,
+		},
+		{
+			name:     "bot comment on a PR",
+			event:    "issue_comment",
+			payload:  `{"action":"created","repository":{"full_name":"owner/repo"},"issue":{"number":42,"pull_request":{"url":"https://api.github.com/repos/owner/repo/pulls/42"}},"comment":{"body":"/rerun-failed","author_association":"MEMBER","user":{"login":"ci-bot[bot]","type":"Bot"}}}`,
+			wantSkip: true,
+		},
+		{
+			name:     "comment on an issue",
+			event:    "issue_comment",
+			payload:  `{"action":"created","repository":{"full_name":"owner/repo"},"issue":{"number":42}}`,
+			wantSkip: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ReadActionsEvent(getenv(actionsEnv(t, tt.event, tt.payload)))
+			if err != nil {
+				t.Fatalf("ReadActionsEvent: %v", err)
+			}
+			if ev.Repo != "owner/repo" {
+				t.Errorf("Repo = %q", ev.Repo)
+			}
+			if strings.Join(ev.Targets, " ") != strings.Join(tt.wantTargets, " ") {
+				t.Errorf("Targets = %v, want %v", ev.Targets, tt.wantTargets)
+			}
+			if ev.Branch != tt.wantBranch {
+				t.Errorf("Branch = %q, want %q", ev.Branch, tt.wantBranch)
+			}
+			if (ev.Skip != "") != tt.wantSkip {
+				t.Errorf("Skip = %q, want skip %v", ev.Skip, tt.wantSkip)
+			}
+		})
+	}
+
+	env := actionsEnv(t, "push", `{}`)
+	if _, err := ReadActionsEvent(getenv(env)); err == nil {
+		t.Error("Expected unsupported events to be rejected")
+	}
+	env = actionsEnv(t, "schedule", `{}`)
+	delete(env, "GITHUB_TOKEN")
+	if _, err := ReadActionsEvent(getenv(env)); err == nil {
+		t.Error("Expected a missing token to be reported")
+	}
+
+	env = actionsEnv(t, "schedule", `{}`)
+	env["GITHUB_SERVER_URL"] = "https://ghes.example.com"
+	if _, err := ReadActionsEvent(getenv(env)); err == nil {
+		t.Error("Expected GITHUB_TOKEN alone to be refused on GitHub Enterprise Server")
+	}
+	env["GH_ENTERPRISE_TOKEN"] = "token"
+	ev, err := ReadActionsEvent(getenv(env))
+	if err != nil {
+		t.Fatalf("ReadActionsEvent with GH_ENTERPRISE_TOKEN: %v", err)
+	}
+	if ev.Repo != "ghes.example.com/owner/repo" {
+		t.Errorf("Repo = %q, want the enterprise host prefixed", ev.Repo)
+	}
+}
+
+func TestRerunner_RunForEvent(t *testing.T) {
+	env := actionsEnv(t, "workflow_run",
+		`{"action":"completed","repository":{"full_name":"owner/repo"},"workflow_run":{"id":111,"status":"completed","conclusion":"failure","html_url":"https://github.com/owner/repo/actions/runs/111"}}`)
+	ev, err := ReadActionsEvent(getenv(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reran []int64
+	mock := &mockGHClient{
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			return &gh.WorkflowRun{ID: runID, Name: "CI", RunNumber: 9, RunAttempt: 1, HeadBranch: "main",
+				Status: "completed", Conclusion: "failure", CreatedAt: time.Now()}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			reran = append(reran, runID)
+			return nil
+		},
+	}
+
+	opts := Options{}
+	ev.Apply(&opts)
+	if opts.MaxAttempts != defaultMaxAttempts {
+		t.Errorf("Expected workflow_run to default --max-attempts to %d, got %d", defaultMaxAttempts, opts.MaxAttempts)
+	}
+	if err := NewRerunner(mock, opts).RunForEvent(ev, getenv(env)); err != nil {
+		t.Fatalf("RunForEvent: %v", err)
+	}
+	if len(reran) != 1 || reran[0] != 111 {
+		t.Errorf("Expected run 111 to be rerun, got %v", reran)
+	}
+
+	out, _ := os.ReadFile(env["GITHUB_OUTPUT"])
+	if !strings.Contains(string(out), "triggered=1\n") || !strings.Contains(string(out), "rerun-ids=111\n") {
+		t.Errorf("Unexpected step outputs:\n%s", out)
+	}
+	md, _ := os.ReadFile(env["GITHUB_STEP_SUMMARY"])
+	if !strings.Contains(string(md), "| ✅ rerun | [CI #9](") {
+		t.Errorf("Unexpected job summary:\n%s", md)
+	}
+}
@@ -0,0 +1,91 @@
+package rerunner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/corneliusroemer/gh-rerun-failed/internal/git"
+)
+
+// currentBranch and remoteURLs read the local checkout. They are variables so
+// tests can stub out git.
+var (
+	currentBranch = git.CurrentBranch
+	remoteURLs    = git.RemoteURLs
+	revParse      = git.RevParse
+	isBranch      = git.IsBranch
+)
+
+// hasSelector reports whether the user named what to scan explicitly, in
+// which case no branch default applies.
+func (r *Rerunner) hasSelector() bool {
+	return r.opts.Branch != "" || r.opts.AllBranches || r.opts.AllOpenPRs ||
+		r.opts.PRNumber != 0 || r.opts.Commit != "" || r.opts.Range != "" ||
+		len(r.opts.Targets) > 0
+}
+
+// resolveBranch defaults the scan to the checked-out branch when no selector
+// was given, matching "rerun failed runs on the current branch". The branch's
+// upstream name is used when it differs from the local name, and an upstream
+// on a fork additionally restricts runs to that fork's head repository. The
+// local checkout is only consulted when it is a clone of the target
+// repository; otherwise, or on a detached HEAD, the whole repository is
+// scanned as before.
+func (r *Rerunner) resolveBranch() {
+	if r.hasSelector() || r.opts.Repo != "" {
+		return
+	}
+
+	target := r.client.Repo()
+	fallback := func(reason string) {
+		fmt.Printf("Note: %s; scanning all branches of %s/%s\n", reason, target.Owner, target.Name)
+	}
+
+	if !r.checkoutOf(target) {
+		fallback("current directory is not a checkout of the target repository")
+		return
+	}
+	b, err := currentBranch()
+	if err != nil {
+		fallback(fmt.Sprintf("could not detect the current branch (%v)", err))
+		return
+	}
+
+	r.opts.Branch = b.Name
+	if b.Upstream == "" {
+		fmt.Printf("Defaulting to current branch %s (no upstream; use --all-branches to scan every branch)\n", b.Name)
+		return
+	}
+	r.opts.Branch = b.Upstream
+
+	upstream, err := repository.Parse(b.RemoteURL)
+	if err == nil && !sameRepo(upstream, target) {
+		r.headRepo = upstream.Owner + "/" + upstream.Name
+		fmt.Printf("Defaulting to current branch %s (upstream %s/%s on fork %s; use --all-branches to scan every branch)\n",
+			b.Name, b.Remote, b.Upstream, r.headRepo)
+		return
+	}
+	fmt.Printf("Defaulting to current branch %s (upstream %s/%s; use --all-branches to scan every branch)\n",
+		b.Name, b.Remote, b.Upstream)
+}
+
+// checkoutOf reports whether any remote of the local checkout points at repo.
+func (r *Rerunner) checkoutOf(repo repository.Repository) bool {
+	urls, err := remoteURLs()
+	if err != nil {
+		return false
+	}
+	for _, u := range urls {
+		if remote, err := repository.Parse(u); err == nil && sameRepo(remote, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameRepo(a, b repository.Repository) bool {
+	return strings.EqualFold(a.Host, b.Host) &&
+		strings.EqualFold(a.Owner, b.Owner) &&
+		strings.EqualFold(a.Name, b.Name)
+}
@@ -0,0 +1,137 @@
+package rerunner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+	"github.com/corneliusroemer/gh-rerun-failed/internal/git"
+)
+
+func stubGit(t *testing.T, branch *git.Branch, branchErr error, remotes ...string) {
+	t.Helper()
+	origBranch, origRemotes := currentBranch, remoteURLs
+	t.Cleanup(func() { currentBranch, remoteURLs = origBranch, origRemotes })
+	currentBranch = func() (*git.Branch, error) { return branch, branchErr }
+	remoteURLs = func() ([]string, error) { return remotes, nil }
+}
+
+func TestRerunner_ResolveBranch(t *testing.T) {
+	const origin = "git@github.com:owner/repo.git"
+	const fork = "https://github.com/someone/repo.git"
+
+	tests := []struct {
+		name         string
+		opts         Options
+		branch       *git.Branch
+		branchErr    error
+		remotes      []string
+		wantBranch   string
+		wantHeadRepo string
+	}{
+		{
+			name:       "upstream on origin",
+			branch:     &git.Branch{Name: "feat", Remote: "origin", RemoteURL: origin, Upstream: "feature/x"},
+			remotes:    []string{origin},
+			wantBranch: "feature/x",
+		},
+		{
+			name:         "upstream on a fork",
+			branch:       &git.Branch{Name: "fix", Remote: "fork", RemoteURL: fork, Upstream: "fix"},
+			remotes:      []string{origin, fork},
+			wantBranch:   "fix",
+			wantHeadRepo: "someone/repo",
+		},
+		{
+			name:       "no upstream",
+			branch:     &git.Branch{Name: "local-only"},
+			remotes:    []string{origin},
+			wantBranch: "local-only",
+		},
+		{
+			name:      "detached HEAD",
+			branchErr: errors.New("detached"),
+			remotes:   []string{origin},
+		},
+		{
+			name:    "checkout of another repository",
+			branch:  &git.Branch{Name: "main", Remote: "origin", RemoteURL: fork, Upstream: "main"},
+			remotes: []string{fork},
+		},
+		{
+			name:    "--all-branches",
+			opts:    Options{AllBranches: true},
+			branch:  &git.Branch{Name: "main", Remote: "origin", RemoteURL: origin, Upstream: "main"},
+			remotes: []string{origin},
+		},
+		{
+			name:    "--repo override",
+			opts:    Options{Repo: "owner/repo"},
+			branch:  &git.Branch{Name: "main", Remote: "origin", RemoteURL: origin, Upstream: "main"},
+			remotes: []string{origin},
+		},
+		{
+			name:       "explicit --branch wins",
+			opts:       Options{Branch: "release"},
+			branch:     &git.Branch{Name: "main", Remote: "origin", RemoteURL: origin, Upstream: "main"},
+			remotes:    []string{origin},
+			wantBranch: "release",
+		},
+		{
+			name:    "PR selector",
+			opts:    Options{PRNumber: 7},
+			branch:  &git.Branch{Name: "main", Remote: "origin", RemoteURL: origin, Upstream: "main"},
+			remotes: []string{origin},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubGit(t, tt.branch, tt.branchErr, tt.remotes...)
+			r := NewRerunner(&mockGHClient{}, tt.opts)
+			r.resolveBranch()
+			if r.opts.Branch != tt.wantBranch {
+				t.Errorf("Branch = %q, want %q", r.opts.Branch, tt.wantBranch)
+			}
+			if r.headRepo != tt.wantHeadRepo {
+				t.Errorf("headRepo = %q, want %q", r.headRepo, tt.wantHeadRepo)
+			}
+		})
+	}
+}
+
+func TestRerunner_FilterRuns_HeadRepo(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{})
+	r.headRepo = "someone/repo"
+	runs := r.filterRuns([]gh.WorkflowRun{
+		{ID: 1, HeadRepository: gh.RepoRef{FullName: "someone/repo"}},
+		{ID: 2, HeadRepository: gh.RepoRef{FullName: "owner/repo"}},
+	})
+	if len(runs) != 1 || runs[0].ID != 1 {
+		t.Errorf("Expected only the fork's run to be kept, got %+v", runs)
+	}
+}
+
+func TestRerunner_ResolveCommit(t *testing.T) {
+	const local = "1111111111111111111111111111111111111111"
+	const remote = "2222222222222222222222222222222222222222"
+	origRevParse := revParse
+	t.Cleanup(func() { revParse = origRevParse })
+	revParse = func(ref string) (string, error) { return local, nil }
+	mock := &mockGHClient{
+		fetchCommitFunc: func(sha string) (*gh.Commit, error) {
+			return &gh.Commit{SHA: remote}, nil
+		},
+	}
+
+	stubGit(t, nil, nil, "git@github.com:owner/repo.git")
+	if sha, err := NewRerunner(mock, Options{}).resolveCommit("HEAD~3"); err != nil || sha != local {
+		t.Errorf("Expected a checkout of the target to resolve locally, got %q, %v", sha, err)
+	}
+
+	// The target came from GH_REPO while the checkout is another repository.
+	stubGit(t, nil, nil, "https://github.com/someone/other.git")
+	if sha, err := NewRerunner(mock, Options{}).resolveCommit("HEAD~3"); err != nil || sha != remote {
+		t.Errorf("Expected an unrelated checkout to be ignored, got %q, %v", sha, err)
+	}
+}
@@ -0,0 +1,267 @@
+package rerunner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+type runKey struct {
+	workflow string
+	sha      string
+	event    string
+}
+
+func keyOf(run gh.WorkflowRun) runKey {
+	workflow := run.Name
+	if run.WorkflowID != 0 {
+		workflow = fmt.Sprint(run.WorkflowID)
+	}
+	return runKey{workflow: workflow, sha: run.HeadSha, event: run.Event}
+}
+
+// dedupeLatest keeps only the newest run per (workflow, head SHA, event).
+// Multiple triggers of the same workflow on the same commit would otherwise
+// each get rerun. It returns the kept runs and the number collapsed.
+func dedupeLatest(runs []gh.WorkflowRun) ([]gh.WorkflowRun, int) {
+	latest := make(map[runKey]int, len(runs))
+	var kept []gh.WorkflowRun
+	for _, run := range runs {
+		key := keyOf(run)
+		i, ok := latest[key]
+		if !ok {
+			latest[key] = len(kept)
+			kept = append(kept, run)
+			continue
+		}
+		if newer(run, kept[i]) {
+			kept[i] = run
+		}
+	}
+	return kept, len(runs) - len(kept)
+}
+
+func newer(a, b gh.WorkflowRun) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+type branchKey struct {
+	workflowID int64
+	branch     string
+}
+
+// skipSuperseded drops failures that no longer matter because the same
+// workflow has since succeeded on the same branch, either on a newer commit or
+// in a later attempt of the very same run.
+func (r *Rerunner) skipSuperseded(runs []gh.WorkflowRun) ([]gh.WorkflowRun, []skippedRun) {
+	latestSuccess := make(map[branchKey]*gh.WorkflowRun)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	seen := make(map[branchKey]bool)
+	for _, run := range runs {
+		key := branchKey{workflowID: run.WorkflowID, branch: run.HeadBranch}
+		if key.workflowID == 0 || key.branch == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key branchKey) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			filter := gh.RunFilter{
+				Branch:   key.branch,
+				Status:   "success",
+				Workflow: fmt.Sprint(key.workflowID),
+			}
+			successes, err := r.collectRuns(filter, 1, nil)
+			if err != nil {
+				fmt.Printf("Warning: could not check for newer successful runs of workflow %d on %s: %v\n",
+					key.workflowID, key.branch, err)
+				return
+			}
+			if len(successes) > 0 {
+				mu.Lock()
+				latestSuccess[key] = &successes[0]
+				mu.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	var kept []gh.WorkflowRun
+	var skipped []skippedRun
+	for _, run := range runs {
+		success := latestSuccess[branchKey{workflowID: run.WorkflowID, branch: run.HeadBranch}]
+		switch {
+		case success == nil:
+			kept = append(kept, run)
+		case success.ID == run.ID:
+			skipped = append(skipped, skippedRun{run: run, reason: fmt.Sprintf("superseded: attempt %d passed", success.RunAttempt)})
+		case success.CreatedAt.After(run.CreatedAt) && success.HeadSha == run.HeadSha:
+			skipped = append(skipped, skippedRun{run: run, reason: fmt.Sprintf("superseded: passed in newer run #%d", success.RunNumber)})
+		case success.CreatedAt.After(run.CreatedAt):
+			skipped = append(skipped, skippedRun{run: run, reason: fmt.Sprintf("superseded: passed on newer commit %s (#%d)", gh.ShortSha(success.HeadSha), success.RunNumber)})
+		default:
+			kept = append(kept, run)
+		}
+	}
+	return kept, skipped
+}
+
+// activeStatuses are the run states that mean an attempt is already in flight:
+// besides queued and running, runs held by an environment approval (waiting)
+// or a concurrency group (pending), and ones GitHub has only just registered
+// (requested).
+var activeStatuses = []string{"requested", "queued", "pending", "waiting", "in_progress"}
+
+// activeKey is a branchKey qualified by the head repository, so a fork's
+// branch does not match the base repository's branch of the same name.
+type activeKey struct {
+	branchKey
+	repo string
+}
+
+func activeKeyOf(run gh.WorkflowRun) activeKey {
+	return activeKey{
+		branchKey: branchKey{workflowID: run.WorkflowID, branch: run.HeadBranch},
+		repo:      strings.ToLower(run.HeadRepository.FullName),
+	}
+}
+
+// fetchActiveRuns looks up, per workflow, branch and head repository, the
+// newest run that is still in one of the activeStatuses.
+func (r *Rerunner) fetchActiveRuns(runs []gh.WorkflowRun) map[activeKey]*gh.WorkflowRun {
+	active := make(map[activeKey]*gh.WorkflowRun)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	seen := make(map[activeKey]bool)
+	for _, run := range runs {
+		key := activeKeyOf(run)
+		if key.workflowID == 0 || key.branch == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		for _, status := range activeStatuses {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(key activeKey, status string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				filter := gh.RunFilter{
+					Branch:   key.branch,
+					Status:   status,
+					Workflow: fmt.Sprint(key.workflowID),
+				}
+				sameRepo := func(run gh.WorkflowRun) bool { return activeKeyOf(run).repo == key.repo }
+				found, err := r.collectRuns(filter, 1, sameRepo)
+				if err != nil || len(found) == 0 {
+					return
+				}
+				mu.Lock()
+				if cur := active[key]; cur == nil || newer(found[0], *cur) {
+					active[key] = &found[0]
+				}
+				mu.Unlock()
+			}(key, status)
+		}
+	}
+	wg.Wait()
+	return active
+}
+
+// inFlight re-checks a run right before it is rerun. It reports a reason when
+// the run itself already has a queued or running attempt, or when a newer run
+// of the same workflow on the same branch of the same repository is still
+// going. Older unfinished runs, such as a deployment waiting for approval, do
+// not block it.
+func (r *Rerunner) inFlight(run gh.WorkflowRun, active map[activeKey]*gh.WorkflowRun) (string, bool) {
+	if run.Status != "" && run.Status != "completed" {
+		return fmt.Sprintf("attempt %d is already %s", run.RunAttempt, run.Status), true
+	}
+
+	if fresh, err := r.client.FetchWorkflowRun(run.ID); err == nil && fresh.Status != "" && fresh.Status != "completed" {
+		return fmt.Sprintf("attempt %d is already %s", fresh.RunAttempt, fresh.Status), true
+	}
+
+	if other := active[activeKeyOf(run)]; other != nil && other.ID != run.ID && newer(*other, run) {
+		return fmt.Sprintf("newer run #%d of this workflow is already %s", other.RunNumber, other.Status), true
+	}
+	return "", false
+}
+
+// splitExhausted separates runs that have already reached the --max-attempts
+// cap; retrying those again is unlikely to help.
+func splitExhausted(runs []gh.WorkflowRun, maxAttempts int) ([]gh.WorkflowRun, []gh.WorkflowRun) {
+	if maxAttempts <= 0 {
+		return runs, nil
+	}
+
+	var kept, exhausted []gh.WorkflowRun
+	for _, run := range runs {
+		if run.RunAttempt >= maxAttempts {
+			exhausted = append(exhausted, run)
+			continue
+		}
+		kept = append(kept, run)
+	}
+	return kept, exhausted
+}
+
+var reviewDecisions = map[string]bool{
+	"APPROVED":          true,
+	"CHANGES_REQUESTED": true,
+	"REVIEW_REQUIRED":   true,
+}
+
+// normalizeReviewDecision maps user input such as "approved" or
+// "changes-requested" onto GitHub's PullRequestReviewDecision values.
+func normalizeReviewDecision(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, "-", "_"))
+}
+
+func (r *Rerunner) validatePRFilters() error {
+	if r.opts.ReviewDecision != "" && !reviewDecisions[normalizeReviewDecision(r.opts.ReviewDecision)] {
+		return fmt.Errorf("invalid --review %q: expected approved, changes-requested or review-required", r.opts.ReviewDecision)
+	}
+	return nil
+}
+
+// matchPR decides whether an open PR is worth scanning for failed runs.
+func (r *Rerunner) matchPR(pr gh.PullRequest) bool {
+	if pr.IsDraft && !r.opts.IncludeDrafts {
+		return false
+	}
+	if r.actor != "" && !strings.EqualFold(pr.Author.Login, r.actor) {
+		return false
+	}
+	if r.opts.Author != "" && !strings.EqualFold(pr.Author.Login, r.opts.Author) {
+		return false
+	}
+	if r.opts.BaseBranch != "" && !globToRegexp(r.opts.BaseBranch, false).MatchString(pr.BaseRefName) {
+		return false
+	}
+	if r.opts.ReviewDecision != "" && pr.ReviewDecision != normalizeReviewDecision(r.opts.ReviewDecision) {
+		return false
+	}
+	for _, label := range r.opts.Labels {
+		if !slices.ContainsFunc(pr.Labels, func(l string) bool { return strings.EqualFold(l, label) }) {
+			return false
+		}
+	}
+	return true
+}
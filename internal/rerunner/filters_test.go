@@ -0,0 +1,186 @@
+package rerunner
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestDedupeLatest(t *testing.T) {
+	now := time.Now()
+	runs := []gh.WorkflowRun{
+		{ID: 1, WorkflowID: 10, HeadSha: "a", Event: "push", CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, WorkflowID: 10, HeadSha: "a", Event: "push", CreatedAt: now},
+		{ID: 3, WorkflowID: 10, HeadSha: "a", Event: "pull_request", CreatedAt: now},
+		{ID: 4, WorkflowID: 11, HeadSha: "a", Event: "push", CreatedAt: now},
+		{ID: 5, WorkflowID: 10, HeadSha: "b", Event: "push", CreatedAt: now},
+	}
+
+	kept, collapsed := dedupeLatest(runs)
+	if collapsed != 1 {
+		t.Errorf("Expected 1 collapsed run, got %d", collapsed)
+	}
+
+	var ids []int64
+	for _, run := range kept {
+		ids = append(ids, run.ID)
+	}
+	want := []int64{2, 3, 4, 5}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected runs %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("Expected runs %v, got %v", want, ids)
+			break
+		}
+	}
+}
+
+func TestRerunner_SkipSuperseded(t *testing.T) {
+	now := time.Now()
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "success" {
+				t.Errorf("Expected a lookup for successful runs, got status %q", filter.Status)
+			}
+			switch filter.Workflow {
+			case "10":
+				return []gh.WorkflowRun{{ID: 100, WorkflowID: 10, HeadSha: "bbbbbbbbbb", CreatedAt: now}}, nil
+			case "11":
+				return []gh.WorkflowRun{{ID: 2, WorkflowID: 11, RunAttempt: 3, CreatedAt: now.Add(-2 * time.Hour)}}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	runs := []gh.WorkflowRun{
+		{ID: 1, WorkflowID: 10, HeadBranch: "main", HeadSha: "aaaaaaaaaa", CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, WorkflowID: 11, HeadBranch: "main", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: 3, WorkflowID: 12, HeadBranch: "main", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	r := NewRerunner(mock, Options{SkipSuperseded: true})
+	kept, skipped := r.skipSuperseded(runs)
+	if len(kept) != 1 || kept[0].ID != 3 {
+		t.Errorf("Expected only run 3 to be kept, got %+v", kept)
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("Expected 2 skipped runs, got %+v", skipped)
+	}
+	if skipped[0].reason != "superseded: passed on newer commit bbbbbbb (#0)" {
+		t.Errorf("Unexpected reason %q", skipped[0].reason)
+	}
+	if skipped[1].reason != "superseded: attempt 3 passed" {
+		t.Errorf("Unexpected reason %q", skipped[1].reason)
+	}
+}
+
+func TestRerunner_Run_SkipsInFlight(t *testing.T) {
+	now := time.Now()
+	var mu sync.Mutex
+	var rerun []int64
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			switch {
+			case filter.Status == "failure":
+				return []gh.WorkflowRun{
+					{ID: 1, Name: "A", WorkflowID: 10, HeadBranch: "main", Status: "completed", CreatedAt: now},
+					{ID: 2, Name: "B", WorkflowID: 20, HeadBranch: "main", Status: "completed", CreatedAt: now},
+					{ID: 3, Name: "C", WorkflowID: 30, HeadBranch: "main", Status: "completed", CreatedAt: now},
+					{ID: 4, Name: "D", WorkflowID: 40, HeadBranch: "main", Status: "completed", CreatedAt: now},
+					{ID: 5, Name: "E", WorkflowID: 50, HeadBranch: "main", Status: "completed", CreatedAt: now},
+				}, nil
+			case filter.Status == "waiting" && filter.Workflow == "20":
+				// Held for an environment approval, which is in flight too.
+				return []gh.WorkflowRun{{ID: 9, WorkflowID: 20, Status: "waiting", CreatedAt: now}}, nil
+			case filter.Status == "waiting" && filter.Workflow == "40":
+				// An older deployment still waiting must not block newer failures.
+				return []gh.WorkflowRun{{ID: 8, WorkflowID: 40, Status: "waiting", CreatedAt: now.Add(-time.Hour)}}, nil
+			case filter.Status == "in_progress" && filter.Workflow == "50":
+				// A fork's branch of the same name is a different branch.
+				return []gh.WorkflowRun{{ID: 10, WorkflowID: 50, Status: "in_progress", CreatedAt: now,
+					HeadRepository: gh.RepoRef{FullName: "someone/fork"}}}, nil
+			}
+			return nil, nil
+		},
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			status := "completed"
+			if runID == 3 {
+				status = "queued"
+			}
+			return &gh.WorkflowRun{ID: runID, Status: status}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			mu.Lock()
+			rerun = append(rerun, runID)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Branch: "main"})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	slices.Sort(rerun)
+	if !slices.Equal(rerun, []int64{1, 4, 5}) {
+		t.Errorf("Expected runs 1, 4 and 5 to be rerun, got %v", rerun)
+	}
+}
+
+func TestSplitExhausted(t *testing.T) {
+	runs := []gh.WorkflowRun{{ID: 1, RunAttempt: 1}, {ID: 2, RunAttempt: 3}, {ID: 3, RunAttempt: 8}}
+
+	kept, exhausted := splitExhausted(runs, 3)
+	if len(kept) != 1 || kept[0].ID != 1 {
+		t.Errorf("Expected only run 1 to be kept, got %+v", kept)
+	}
+	if len(exhausted) != 2 {
+		t.Errorf("Expected 2 exhausted runs, got %+v", exhausted)
+	}
+
+	if kept, _ := splitExhausted(runs, 0); len(kept) != 3 {
+		t.Errorf("Expected no cap without --max-attempts, got %+v", kept)
+	}
+}
+
+func TestRerunner_MatchPR(t *testing.T) {
+	pr := gh.PullRequest{
+		Number:         1,
+		BaseRefName:    "release/1.2",
+		ReviewDecision: "APPROVED",
+		Labels:         []string{"ci-flaky", "backport"},
+		Author:         gh.User{Login: "octocat"},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want bool
+	}{
+		{"no filters", Options{}, true},
+		{"base glob", Options{BaseBranch: "release/*"}, true},
+		{"base mismatch", Options{BaseBranch: "main"}, false},
+		{"label", Options{Labels: []string{"CI-Flaky"}}, true},
+		{"all labels required", Options{Labels: []string{"ci-flaky", "urgent"}}, false},
+		{"approved", Options{ReviewDecision: "approved"}, true},
+		{"changes requested", Options{ReviewDecision: "changes-requested"}, false},
+		{"author", Options{Author: "octocat"}, true},
+		{"other author", Options{Author: "hubot"}, false},
+	}
+
+	for _, tt := range tests {
+		r := NewRerunner(&mockGHClient{}, tt.opts)
+		if got := r.matchPR(pr); got != tt.want {
+			t.Errorf("%s: matchPR = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := NewRerunner(&mockGHClient{}, Options{ReviewDecision: "lgtm"}).validatePRFilters(); err == nil {
+		t.Error("Expected an error for an unknown review state")
+	}
+}
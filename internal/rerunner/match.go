@@ -0,0 +1,139 @@
+package rerunner
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// globToRegexp converts a shell-style glob (*, ?) into an anchored regexp.
+// Unlike path.Match, * also matches "/" so job names like "build / test" and
+// workflow paths can be matched with a single pattern.
+func globToRegexp(glob string, ignoreCase bool) *regexp.Regexp {
+	var b strings.Builder
+	if ignoreCase {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func hasGlob(s string) bool {
+	return strings.ContainsAny(s, "*?")
+}
+
+// workflowSelector matches a run's workflow by numeric ID, by file path
+// (".github/workflows/ci.yml" or just "ci.yml") or by name.
+type workflowSelector struct {
+	raw    string
+	id     int64
+	isPath bool
+	re     *regexp.Regexp
+}
+
+func parseWorkflowSelector(s string) workflowSelector {
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil && id > 0 {
+		return workflowSelector{raw: s, id: id}
+	}
+	if strings.Contains(s, "/") || strings.HasSuffix(s, ".yml") || strings.HasSuffix(s, ".yaml") {
+		return workflowSelector{raw: s, isPath: true, re: globToRegexp(s, false)}
+	}
+	return workflowSelector{raw: s, re: globToRegexp(s, true)}
+}
+
+func (w workflowSelector) matches(run gh.WorkflowRun) bool {
+	if w.id != 0 {
+		return run.WorkflowID == w.id
+	}
+	if w.isPath {
+		// Paths may carry a ref suffix, e.g. ".github/workflows/ci.yml@refs/heads/main".
+		p, _, _ := strings.Cut(run.Path, "@")
+		if strings.Contains(w.raw, "/") {
+			return w.re.MatchString(p)
+		}
+		return w.re.MatchString(path.Base(p))
+	}
+	return w.re.MatchString(run.Name)
+}
+
+// serverSide returns the identifier the per-workflow runs endpoint accepts for
+// this selector, or "" if it can only be applied client-side.
+func (w workflowSelector) serverSide() string {
+	if w.id != 0 {
+		return w.raw
+	}
+	if w.isPath && !hasGlob(w.raw) {
+		dir, file := path.Split(w.raw)
+		if dir == "" || dir == ".github/workflows/" {
+			return file
+		}
+	}
+	return ""
+}
+
+func parseWorkflowSelectors(raw []string) []workflowSelector {
+	selectors := make([]workflowSelector, 0, len(raw))
+	for _, s := range raw {
+		selectors = append(selectors, parseWorkflowSelector(s))
+	}
+	return selectors
+}
+
+func matchesAnyWorkflow(selectors []workflowSelector, run gh.WorkflowRun) bool {
+	for _, s := range selectors {
+		if s.matches(run) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern compiles a name pattern. Patterns wrapped in slashes are
+// regular expressions (/^integration-.*$/); everything else is a glob matched
+// case-insensitively against the whole name.
+func compilePattern(s string) (*regexp.Regexp, error) {
+	if len(s) >= 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		return re, nil
+	}
+	return globToRegexp(s, true), nil
+}
+
+func compilePatterns(raw []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(raw))
+	for _, s := range raw {
+		re, err := compilePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
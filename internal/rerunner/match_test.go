@@ -0,0 +1,87 @@
+package rerunner
+
+import (
+	"testing"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestWorkflowSelector(t *testing.T) {
+	run := gh.WorkflowRun{Name: "Lint", WorkflowID: 42, Path: ".github/workflows/lint.yml"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"Lint", true},
+		{"lint", true},
+		{"Li*", true},
+		{"Build", false},
+		{"42", true},
+		{"43", false},
+		{"lint.yml", true},
+		{".github/workflows/lint.yml", true},
+		{".github/workflows/*.yml", true},
+		{"l*.yaml", false},
+	}
+
+	for _, tt := range tests {
+		if got := parseWorkflowSelector(tt.selector).matches(run); got != tt.want {
+			t.Errorf("selector %q matches = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestRerunner_RunFilters_ServerSideWorkflows(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{Workflows: []string{"42", ".github/workflows/ci.yml"}})
+	filters := r.runFilters("main")
+	if len(filters) != 2 || filters[0].Workflow != "42" || filters[1].Workflow != "ci.yml" {
+		t.Errorf("Expected per-workflow queries for 42 and ci.yml, got %+v", filters)
+	}
+
+	// A name selector cannot be resolved server-side, so everything is fetched.
+	r = NewRerunner(&mockGHClient{}, Options{Workflows: []string{"42", "Build*"}})
+	filters = r.runFilters("main")
+	if len(filters) != 1 || filters[0].Workflow != "" {
+		t.Errorf("Expected a single unfiltered query, got %+v", filters)
+	}
+}
+
+func TestRerunner_FilterRuns_ExcludeWorkflow(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{ExcludeWorkflows: []string{"lint.yml"}})
+	runs := r.filterRuns([]gh.WorkflowRun{
+		{ID: 1, Name: "Lint", Path: ".github/workflows/lint.yml"},
+		{ID: 2, Name: "Test", Path: ".github/workflows/test.yml"},
+	})
+	if len(runs) != 1 || runs[0].ID != 2 {
+		t.Errorf("Expected only run 2 to remain, got %+v", runs)
+	}
+}
+
+func TestFilterRunsByJobs(t *testing.T) {
+	runs := []gh.WorkflowRun{{ID: 1}, {ID: 2}, {ID: 3}}
+	failed := map[int64][]gh.WorkflowJob{
+		1: {{Name: "integration-linux"}, {Name: "unit"}},
+		2: {{Name: "type-check"}},
+		3: {{Name: "lint"}, {Name: "unit"}},
+	}
+
+	include, _ := compilePatterns([]string{"integration-*"})
+	got, skipped := filterRunsByJobs(runs, failed, jobFilters{include: include})
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("--job: expected only run 1, got %+v", got)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("--job: expected 2 skipped runs, got %+v", skipped)
+	}
+
+	exclude, _ := compilePatterns([]string{"type-check", "/^lint$/"})
+	got, _ = filterRunsByJobs(runs, failed, jobFilters{exclude: exclude})
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Errorf("--exclude-job: expected runs 1 and 3, got %+v", got)
+	}
+
+	if _, err := compilePattern("/(/"); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+}
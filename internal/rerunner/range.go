@@ -0,0 +1,93 @@
+package rerunner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// parseRange splits a git-style A..B range. The head may be omitted when a
+// branch is given, so `--range v1.2.0.. --branch main` means v1.2.0..main.
+func parseRange(rng string, branch string) (base, head string, err error) {
+	base, head, ok := strings.Cut(rng, "..")
+	if !ok || strings.HasPrefix(head, ".") {
+		return "", "", fmt.Errorf("invalid range %q: expected A..B", rng)
+	}
+	if head == "" {
+		head = branch
+	}
+	if base == "" || head == "" {
+		return "", "", fmt.Errorf("invalid range %q: both ends are required (or set --branch for the head)", rng)
+	}
+	return base, head, nil
+}
+
+// resolveRangeHead resolves the head of a range. A branch name is resolved
+// through the API rather than the local branch, which may lag behind the
+// remote and silently leave out the newest commits; the local branch is only
+// used when the remote does not know it.
+func (r *Rerunner) resolveRangeHead(head string) (string, error) {
+	if fullShaRe.MatchString(head) || !isBranch(head) {
+		return r.resolveCommit(head)
+	}
+	commit, err := r.client.FetchCommit(head)
+	if err != nil {
+		fmt.Printf("Warning: could not resolve branch %s on the remote (%v); using the local branch\n", head, err)
+		return r.resolveCommit(head)
+	}
+	return commit.SHA, nil
+}
+
+// fetchRunsForRange collects failed runs for every commit in base..head. The
+// commits are returned as well (tip first) so the caller can show distances.
+func (r *Rerunner) fetchRunsForRange(rng string) ([]gh.WorkflowRun, []gh.Commit, error) {
+	base, head, err := parseRange(rng, r.opts.Branch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseSha, err := r.resolveCommit(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	headSha, err := r.resolveRangeHead(head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commits, err := r.client.FetchCommitRange(baseSha, headSha)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list commits in %s: %w", rng, err)
+	}
+	fmt.Printf("Scanning %d commits in %s\n", len(commits), rng)
+
+	var allRuns []gh.WorkflowRun
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	for _, c := range commits {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sha string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			runs, err := r.fetchFailedRunsForSha(sha)
+			if err != nil {
+				fmt.Printf("Warning: failed to fetch runs for commit %s: %v\n", sha, err)
+				return
+			}
+			if len(runs) > 0 {
+				mu.Lock()
+				allRuns = append(allRuns, runs...)
+				mu.Unlock()
+			}
+		}(c.SHA)
+	}
+	wg.Wait()
+
+	return allRuns, commits, nil
+}
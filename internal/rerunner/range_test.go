@@ -0,0 +1,100 @@
+package rerunner
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		rng, branch string
+		base, head  string
+		wantErr     bool
+	}{
+		{rng: "v1.2.0..main", base: "v1.2.0", head: "main"},
+		{rng: "v1.2.0..", branch: "main", base: "v1.2.0", head: "main"},
+		{rng: "v1.2.0..", wantErr: true},
+		{rng: "..main", wantErr: true},
+		{rng: "v1.2.0...main", wantErr: true},
+		{rng: "main", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		base, head, err := parseRange(tt.rng, tt.branch)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRange(%q) expected an error", tt.rng)
+			}
+			continue
+		}
+		if err != nil || base != tt.base || head != tt.head {
+			t.Errorf("parseRange(%q) = %q, %q, %v; want %q, %q", tt.rng, base, head, err, tt.base, tt.head)
+		}
+	}
+}
+
+func TestRerunner_Run_Range(t *testing.T) {
+	base := "1111111111111111111111111111111111111111"
+	head := "2222222222222222222222222222222222222222"
+
+	var mu sync.Mutex
+	var scanned []string
+	mock := &mockGHClient{
+		fetchCommitRangeFunc: func(b, h string) ([]gh.Commit, error) {
+			if b != base || h != head {
+				t.Errorf("unexpected range %s..%s", b, h)
+			}
+			return []gh.Commit{{SHA: head}, {SHA: "3333333333333333333333333333333333333333"}}, nil
+		},
+		fetchWorkflowRunsForShaFunc: func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			mu.Lock()
+			scanned = append(scanned, sha)
+			mu.Unlock()
+			return nil, nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Range: base + ".." + head, DryRun: true})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sort.Strings(scanned)
+	want := []string{head, "3333333333333333333333333333333333333333"}
+	if len(scanned) != len(want) || scanned[0] != want[0] || scanned[1] != want[1] {
+		t.Errorf("Expected SHAs %v to be scanned, got %v", want, scanned)
+	}
+}
+
+func TestRerunner_ResolveRangeHead(t *testing.T) {
+	const stale = "1111111111111111111111111111111111111111"
+	const tip = "2222222222222222222222222222222222222222"
+	stubGit(t, nil, nil, "git@github.com:owner/repo.git")
+	origRevParse, origIsBranch := revParse, isBranch
+	t.Cleanup(func() { revParse, isBranch = origRevParse, origIsBranch })
+	revParse = func(ref string) (string, error) { return stale, nil }
+	isBranch = func(name string) bool { return name == "main" }
+
+	var fetched []string
+	mock := &mockGHClient{
+		fetchCommitFunc: func(ref string) (*gh.Commit, error) {
+			fetched = append(fetched, ref)
+			return &gh.Commit{SHA: tip}, nil
+		},
+	}
+	r := NewRerunner(mock, Options{})
+
+	// The local main has not been pulled; the remote tip must win.
+	if sha, err := r.resolveRangeHead("main"); err != nil || sha != tip {
+		t.Errorf("Expected main to resolve to the remote tip, got %q, %v", sha, err)
+	}
+	if sha, err := r.resolveRangeHead("HEAD~3"); err != nil || sha != stale {
+		t.Errorf("Expected other refs to resolve locally, got %q, %v", sha, err)
+	}
+	if len(fetched) != 1 || fetched[0] != "main" {
+		t.Errorf("Expected only the branch to be looked up via the API, got %v", fetched)
+	}
+}
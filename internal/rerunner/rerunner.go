@@ -1,7 +1,11 @@
 package rerunner
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -11,36 +15,120 @@ import (
 	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
 )
 
+var fullShaRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 type Options struct {
 	Repo             string
 	Branch           string
 	Limit            int
-	Since            time.Duration
+	Since            string
+	Until            string
 	PRNumber         int
+	Commit           string
+	Range            string
+	AllBranches      bool
+	Targets          []string
+	Stdin            io.Reader
 	AllOpenPRs       bool
 	DryRun           bool
 	FailedOnly       bool
 	IncludeDrafts    bool
 	IncludeCancelled bool
 	IncludeTimedOut  bool
+	Workflows        []string
+	ExcludeWorkflows []string
+	Jobs             []string
+	ExcludeJobs      []string
+	SkipSuperseded   bool
+	MaxAttempts      int
+	Event            string
+	Actor            string
+	Mine             bool
+	Labels           []string
+	BaseBranch       string
+	Author           string
+	ReviewDecision   string
+	Watch            bool
+	WatchInterval    time.Duration
+	UntilGreen       bool
+	Retries          int
+	RetryDelay       time.Duration
+	Deadline         time.Duration
+	Interval         time.Duration
+	StateFile        string
 }
 
 type Rerunner struct {
-	client gh.GHClient
-	opts   Options
+	client  gh.GHClient
+	opts    Options
+	include []workflowSelector
+	exclude []workflowSelector
+	actor   string
+	since   time.Time
+	until   time.Time
+
+	// headRepo is the OWNER/REPO of a fork whose branch was picked up as the
+	// default; runs from other repositories with the same branch name are
+	// dropped.
+	headRepo string
+
+	// retried holds the run attempts Serve has already rerun; nil outside
+	// of serve mode.
+	retried map[runAttempt]time.Time
+
+	// settledWindow is Serve's default window on completion time; each pass
+	// drops runs last updated before settledAfter. Zero outside of serve or
+	// when --since is set.
+	settledWindow time.Duration
+	settledAfter  time.Time
+}
+
+// jobFilters holds the compiled --job/--exclude-job patterns.
+type jobFilters struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func (f jobFilters) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
 }
 
 func NewRerunner(client gh.GHClient, opts Options) *Rerunner {
 	return &Rerunner{
-		client: client,
-		opts:   opts,
+		client:  client,
+		opts:    opts,
+		include: parseWorkflowSelectors(opts.Workflows),
+		exclude: parseWorkflowSelectors(opts.ExcludeWorkflows),
 	}
 }
 
 func (r *Rerunner) Run() error {
+	_, err := r.run()
+	return err
+}
+
+// run performs one discovery and rerun pass and returns what happened to each
+// candidate, so callers such as Serve can log and remember the decisions.
+func (r *Rerunner) run() (*summary, error) {
+	jobFilter, err := r.compileJobFilters()
+	if err != nil {
+		return nil, err
+	}
+
 	repo := r.client.Repo()
 	fmt.Printf("Targeting repository: %s/%s\n", repo.Owner, repo.Name)
 
+	if err := r.resolveWindow(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := r.resolveActor(); err != nil {
+		return nil, err
+	}
+	if err := r.validatePRFilters(); err != nil {
+		return nil, err
+	}
+	r.resolveBranch()
+
 	terminal := term.FromEnv()
 	width, _, _ := terminal.Size()
 	if width <= 0 {
@@ -62,7 +150,7 @@ func (r *Rerunner) Run() error {
 	commitMsgMap := make(map[string]string)
 	var mu sync.Mutex
 
-	if r.opts.Branch != "" || r.opts.PRNumber == 0 {
+	if r.opts.Range == "" && (r.opts.Branch != "" || r.opts.PRNumber == 0) {
 		commits, err := r.client.FetchCommits(r.opts.Branch, 50)
 		if err == nil {
 			for i, c := range commits {
@@ -73,11 +161,22 @@ func (r *Rerunner) Run() error {
 		}
 	}
 
+	targets, err := r.collectTargets()
+	if err != nil {
+		return nil, err
+	}
+
 	var runs []gh.WorkflowRun
-	var err error
 
-	if r.opts.PRNumber > 0 {
-		runs, err = r.fetchRunsForPR(r.opts.PRNumber)
+	if len(targets) > 0 {
+		runs, err = r.fetchRunsForTargets(targets)
+	} else if r.opts.Range != "" {
+		var commits []gh.Commit
+		runs, commits, err = r.fetchRunsForRange(r.opts.Range)
+		for i, c := range commits {
+			commitMap[c.SHA] = i
+			commitMsgMap[c.SHA] = strings.Split(c.Message, "\n")[0]
+		}
 	} else if r.opts.AllOpenPRs {
 		runs, err = r.fetchRunsForAllOpenPRs()
 	} else {
@@ -85,12 +184,54 @@ func (r *Rerunner) Run() error {
 	}
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+	runs = r.filterRuns(uniqueRuns(runs))
+
+	runs, collapsed := dedupeLatest(runs)
+	if collapsed > 0 {
+		fmt.Printf("Collapsed %d older duplicate runs (same workflow, commit and event)\n", collapsed)
+	}
+
+	sum := &summary{}
+	if r.opts.SkipSuperseded {
+		var superseded []skippedRun
+		runs, superseded = r.skipSuperseded(runs)
+		sum.addSkipped(superseded...)
+	}
+
+	runs, sum.exhausted = splitExhausted(runs, r.opts.MaxAttempts)
+
+	if r.retried != nil {
+		var retried []skippedRun
+		runs, retried = r.skipRetried(runs)
+		sum.addSkipped(retried...)
 	}
 
 	if len(runs) == 0 {
+		sum.print(r.opts.DryRun, r.opts.MaxAttempts)
 		fmt.Println("No failed workflow runs found matching the criteria.")
-		return nil
+		return sum, nil
+	}
+
+	// Sort runs by CreatedAt descending
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+
+	// Job filters need the failed jobs of every candidate before limiting;
+	// otherwise jobs are only fetched for the runs we end up processing.
+	var runFailedJobs map[int64][]gh.WorkflowJob
+	if jobFilter.active() {
+		runFailedJobs = r.fetchFailedJobs(runs)
+		var jobSkipped []skippedRun
+		runs, jobSkipped = filterRunsByJobs(runs, runFailedJobs, jobFilter)
+		sum.addSkipped(jobSkipped...)
+		if len(runs) == 0 {
+			sum.print(r.opts.DryRun, r.opts.MaxAttempts)
+			fmt.Println("No failed workflow runs left after applying job filters.")
+			return sum, nil
+		}
 	}
 
 	totalFound := len(runs)
@@ -101,44 +242,15 @@ func (r *Rerunner) Run() error {
 
 	fmt.Printf("Found %d failed/cancelled workflow runs (processed %d). Starting reruns...\n", totalFound, len(runs))
 
-	// Sort runs by CreatedAt descending
-	sort.Slice(runs, func(i, j int) bool {
-		return runs[i].CreatedAt.After(runs[j].CreatedAt)
-	})
-
 	// Fetch failed jobs for each run to show matrix entries
-	runFailedJobs := make(map[int64][]string)
-	var jobMu sync.Mutex
-	var jobWg sync.WaitGroup
-	jobSem := make(chan struct{}, 10)
-
-	for _, run := range runs {
-		jobWg.Add(1)
-		jobSem <- struct{}{}
-		go func(run gh.WorkflowRun) {
-			defer jobWg.Done()
-			defer func() { <-jobSem }()
-			jobs, err := r.client.FetchWorkflowRunJobs(run.ID)
-			if err == nil {
-				var failed []string
-				for _, j := range jobs {
-					if j.Conclusion == "failure" {
-						failed = append(failed, j.Name)
-					}
-				}
-				if len(failed) > 0 {
-					jobMu.Lock()
-					runFailedJobs[run.ID] = failed
-					jobMu.Unlock()
-				}
-			}
-		}(run)
+	if runFailedJobs == nil {
+		runFailedJobs = r.fetchFailedJobs(runs)
 	}
-	jobWg.Wait()
 
 	if r.opts.DryRun {
 		wfW := 40
 		attW := 3
+		evW := 12
 		brW := 20
 		shaW := 7
 		dateW := 19
@@ -154,12 +266,12 @@ func (r *Rerunner) Run() error {
 		}
 
 		// Header
-		format := "%-40s | %-3s | %-20s | %-7s | %-19s | %-*s | %s\n"
+		format := "%-40s | %-3s | %-12s | %-20s | %-7s | %-19s | %-*s | %s\n"
 		fmt.Printf("\n"+format,
-			"Workflow (+Failed Jobs)", "Att", "Branch@Dist", "SHA", "Created At", maxUrlW, "URL", "Message")
+			"Workflow (+Failed Jobs)", "Att", "Event", "Branch@Dist", "SHA", "Created At", maxUrlW, "URL", "Message")
 
 		// Separator line
-		overhead := 18 + wfW + attW + brW + shaW + dateW + maxUrlW
+		overhead := 21 + wfW + attW + evW + brW + shaW + dateW + maxUrlW
 		msgW := width - overhead
 		if msgW < 20 {
 			msgW = 20
@@ -170,7 +282,7 @@ func (r *Rerunner) Run() error {
 		}
 		fmt.Println(strings.Repeat("-", lineLen))
 
-		rowFormat := "%-40s | %-3d | %-20s | %-7s | %-19s | %-*s | %s\n"
+		rowFormat := "%-40s | %-3d | %-12s | %-20s | %-7s | %-19s | %-*s | %s\n"
 		var wg sync.WaitGroup
 		sem := make(chan struct{}, 5)
 
@@ -181,10 +293,7 @@ func (r *Rerunner) Run() error {
 				defer wg.Done()
 				defer func() { <-sem }()
 
-				sha := run.HeadSha
-				if len(sha) > 7 {
-					sha = sha[:7]
-				}
+				sha := gh.ShortSha(run.HeadSha)
 
 				distance := "HEAD^?"
 				if d, ok := commitMap[run.HeadSha]; ok {
@@ -218,12 +327,20 @@ func (r *Rerunner) Run() error {
 
 				name := run.Name
 				if failed, ok := runFailedJobs[run.ID]; ok {
-					name = fmt.Sprintf("%s (%s)", name, strings.Join(failed, ", "))
+					labels := make([]string, len(failed))
+					for i, job := range failed {
+						labels[i] = job.Name
+						if step, ok := job.FailedStep(); ok {
+							labels[i] += ": " + step.Name
+						}
+					}
+					name = fmt.Sprintf("%s (%s)", name, strings.Join(labels, ", "))
 				}
 
 				fmt.Printf(rowFormat,
 					truncate(name, wfW),
 					run.RunAttempt,
+					truncate(run.Event, evW),
 					truncate(fmt.Sprintf("%s (%s)", run.HeadBranch, distance), brW),
 					sha,
 					createdAt,
@@ -233,13 +350,19 @@ func (r *Rerunner) Run() error {
 			}(run)
 		}
 		wg.Wait()
+		if collapsed > 0 {
+			fmt.Printf("(%d older duplicate runs collapsed into the rows above)\n", collapsed)
+		}
+		sum.print(r.opts.DryRun, r.opts.MaxAttempts)
 		fmt.Println("Dry-run complete. No reruns were triggered.")
-		return nil
+		return sum, nil
 	}
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 5) // Limit concurrency to 5
 
+	active := r.fetchActiveRuns(runs)
+
 	for _, run := range runs {
 		wg.Add(1)
 		sem <- struct{}{}
@@ -248,15 +371,19 @@ func (r *Rerunner) Run() error {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			sha := run.HeadSha
-			if len(sha) > 7 {
-				sha = sha[:7]
+			sha := gh.ShortSha(run.HeadSha)
+
+			if reason, busy := r.inFlight(run, active); busy {
+				sum.addSkipped(skippedRun{run: run, reason: reason})
+				return
 			}
 
 			err := r.client.RerunWorkflow(run.ID, r.opts.FailedOnly)
 			if err != nil {
 				fmt.Printf("✗ Failed to rerun %d (%s): %v\n", run.ID, run.Name, err)
+				sum.addFailed(run)
 			} else {
+				sum.addTriggered(run)
 				fmt.Printf("✓ Triggered rerun for: %s (%s) | #%d (attempt %d) | %s\n",
 					run.Name, run.HeadBranch, run.RunNumber, run.RunAttempt, sha)
 			}
@@ -264,6 +391,13 @@ func (r *Rerunner) Run() error {
 	}
 
 	wg.Wait()
+	sum.print(r.opts.DryRun, r.opts.MaxAttempts)
+
+	var red []gh.WorkflowRun
+	watching := r.opts.Watch || r.opts.UntilGreen
+	if watching && len(sum.triggered) > 0 {
+		red = r.watch(sum.triggered)
+	}
 
 	endRate, err := r.client.GetRateLimit()
 	if err == nil {
@@ -278,9 +412,108 @@ func (r *Rerunner) Run() error {
 	if !r.opts.DryRun {
 		fmt.Println("Done triggering reruns.")
 	}
+	if watching && len(red)+len(sum.failed) > 0 {
+		return sum, fmt.Errorf("%d runs still failing (%d reruns red, %d could not be triggered)",
+			len(red)+len(sum.failed), len(red), len(sum.failed))
+	}
+	return sum, nil
+}
+
+// resolveActor settles which login --actor/--mine refer to, asking the API
+// for the authenticated user in the --mine case.
+func (r *Rerunner) resolveActor() error {
+	r.actor = r.opts.Actor
+	if !r.opts.Mine {
+		return nil
+	}
+
+	user, err := r.client.CurrentUser()
+	if err != nil {
+		return fmt.Errorf("could not determine the authenticated user for --mine: %w", err)
+	}
+	r.actor = user.Login
+	fmt.Printf("Only processing runs and PRs by %s\n", r.actor)
 	return nil
 }
 
+func (r *Rerunner) compileJobFilters() (jobFilters, error) {
+	include, err := compilePatterns(r.opts.Jobs)
+	if err != nil {
+		return jobFilters{}, err
+	}
+	exclude, err := compilePatterns(r.opts.ExcludeJobs)
+	if err != nil {
+		return jobFilters{}, err
+	}
+	return jobFilters{include: include, exclude: exclude}, nil
+}
+
+// fetchFailedJobs returns the failed jobs of each run, keyed by run ID. Runs
+// whose jobs could not be fetched are simply absent.
+func (r *Rerunner) fetchFailedJobs(runs []gh.WorkflowRun) map[int64][]gh.WorkflowJob {
+	runFailedJobs := make(map[int64][]gh.WorkflowJob)
+	var jobMu sync.Mutex
+	var jobWg sync.WaitGroup
+	jobSem := make(chan struct{}, 10)
+
+	for _, run := range runs {
+		jobWg.Add(1)
+		jobSem <- struct{}{}
+		go func(run gh.WorkflowRun) {
+			defer jobWg.Done()
+			defer func() { <-jobSem }()
+			jobs, err := r.client.FetchWorkflowRunJobs(run.ID)
+			if err == nil {
+				var failed []gh.WorkflowJob
+				for _, j := range jobs {
+					if j.Failed() {
+						failed = append(failed, j)
+					}
+				}
+				if len(failed) > 0 {
+					jobMu.Lock()
+					runFailedJobs[run.ID] = failed
+					jobMu.Unlock()
+				}
+			}
+		}(run)
+	}
+	jobWg.Wait()
+	return runFailedJobs
+}
+
+// filterRunsByJobs keeps runs where a failed job matches --job, and drops runs
+// whose failed jobs all match --exclude-job (e.g. a run that only failed its
+// type-check job).
+func filterRunsByJobs(runs []gh.WorkflowRun, runFailedJobs map[int64][]gh.WorkflowJob, f jobFilters) ([]gh.WorkflowRun, []skippedRun) {
+	var kept []gh.WorkflowRun
+	var skipped []skippedRun
+	for _, run := range runs {
+		var failed []string
+		for _, job := range runFailedJobs[run.ID] {
+			failed = append(failed, job.Name)
+		}
+
+		if len(f.include) > 0 && !slices.ContainsFunc(failed, func(name string) bool {
+			return matchesAny(f.include, name)
+		}) {
+			skipped = append(skipped, skippedRun{run: run, reason: "no failed job matches --job"})
+			continue
+		}
+
+		if len(f.exclude) > 0 && len(failed) > 0 && !slices.ContainsFunc(failed, func(name string) bool {
+			return !matchesAny(f.exclude, name)
+		}) {
+			skipped = append(skipped, skippedRun{run: run,
+				reason: fmt.Sprintf("all failed jobs (%s) match --exclude-job", strings.Join(failed, ", "))})
+			continue
+		}
+
+		kept = append(kept, run)
+	}
+	return kept, skipped
+}
+
 func truncate(s string, l int) string {
 	if len(s) > l {
 		if l > 3 {
@@ -296,23 +529,92 @@ func (r *Rerunner) fetchRunsForPR(number int) ([]gh.WorkflowRun, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch PR #%d: %w", number, err)
 	}
-	return r.fetchFailedRunsForSha(pr.HeadRefOid)
+	runs, err := r.fetchFailedRunsForSha(pr.HeadRefOid)
+	if err != nil {
+		return nil, err
+	}
+	return append(runs, r.fetchMergeQueueRuns(*pr)...), nil
 }
 
-func (r *Rerunner) fetchRunsForAllOpenPRs() ([]gh.WorkflowRun, error) {
-	prs, err := r.client.FetchOpenPullRequests()
+// fetchMergeQueueRuns finds failed merge_group runs for a PR sitting in the
+// merge queue. Those block the PR even when its head commit is green.
+func (r *Rerunner) fetchMergeQueueRuns(pr gh.PullRequest) []gh.WorkflowRun {
+	entry := pr.MergeQueueEntry
+	if entry == nil || entry.HeadCommit.Oid == "" {
+		return nil
+	}
+	fmt.Printf("PR #%d is in the merge queue (position %d, %s)\n", pr.Number, entry.Position, entry.State)
+
+	runs, err := r.fetchFailedRunsForSha(entry.HeadCommit.Oid)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch open PRs: %w", err)
+		fmt.Printf("Warning: failed to fetch merge queue runs for PR #%d: %v\n", pr.Number, err)
+		return nil
 	}
 
+	var queueRuns []gh.WorkflowRun
+	for _, run := range runs {
+		if run.Event == "merge_group" {
+			queueRuns = append(queueRuns, run)
+		}
+	}
+
+	if len(queueRuns) > 0 {
+		fmt.Printf("PR #%d: found %d failed merge_group runs on %s\n", pr.Number, len(queueRuns), queueRuns[0].HeadBranch)
+	} else if entry.State == "UNMERGEABLE" {
+		fmt.Printf("Warning: PR #%d is blocked in the merge queue but no failed merge_group runs were found; manual intervention may be required\n", pr.Number)
+	}
+	return queueRuns
+}
+
+func (r *Rerunner) fetchRunsForCommit(ref string) ([]gh.WorkflowRun, error) {
+	sha, err := r.resolveCommit(ref)
+	if err != nil {
+		return nil, err
+	}
+	if sha != ref {
+		fmt.Printf("Resolved %s to %s\n", ref, sha)
+	}
+	return r.fetchFailedRunsForSha(sha)
+}
+
+// resolveCommit turns a short SHA or ref into a full SHA. The local checkout is
+// only consulted when it is a clone of the target repository, since --repo or
+// GH_REPO may point somewhere the local clone knows nothing about.
+func (r *Rerunner) resolveCommit(ref string) (string, error) {
+	if fullShaRe.MatchString(ref) {
+		return ref, nil
+	}
+
+	if r.opts.Repo == "" && r.checkoutOf(r.client.Repo()) {
+		if sha, err := revParse(ref); err == nil {
+			return sha, nil
+		}
+	}
+
+	commit, err := r.client.FetchCommit(ref)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve commit %q: %w", ref, err)
+	}
+	return commit.SHA, nil
+}
+
+// fetchRunsForAllOpenPRs scans open PRs while they are still being paged in:
+// each matching PR is handed to the worker pool as soon as it arrives, and a
+// full pool in turn holds back fetching of the next page.
+func (r *Rerunner) fetchRunsForAllOpenPRs() ([]gh.WorkflowRun, error) {
 	var allRuns []gh.WorkflowRun
+	var listErr error
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 10) // Concurrency limit for PR fetching
 
-	for _, pr := range prs {
-		if pr.IsDraft && !r.opts.IncludeDrafts {
+	for pr, err := range r.client.OpenPullRequests() {
+		if err != nil {
+			listErr = err
+			break
+		}
+		if !r.matchPR(pr) {
 			continue
 		}
 
@@ -327,6 +629,7 @@ func (r *Rerunner) fetchRunsForAllOpenPRs() ([]gh.WorkflowRun, error) {
 				fmt.Printf("Warning: failed to fetch runs for PR #%d: %v\n", p.Number, err)
 				return
 			}
+			runs = append(runs, r.fetchMergeQueueRuns(p)...)
 
 			if len(runs) > 0 {
 				mu.Lock()
@@ -336,15 +639,15 @@ func (r *Rerunner) fetchRunsForAllOpenPRs() ([]gh.WorkflowRun, error) {
 		}(pr)
 	}
 	wg.Wait()
-	return allRuns, nil
-}
 
-func (r *Rerunner) fetchRunsForContextParallel() ([]gh.WorkflowRun, error) {
-	var sinceTime time.Time
-	if r.opts.Since > 0 {
-		sinceTime = time.Now().Add(-r.opts.Since)
+	if listErr != nil {
+		return nil, fmt.Errorf("failed to fetch open PRs: %w", listErr)
 	}
+	return allRuns, nil
+}
 
+// statuses returns the run conclusions that are considered worth rerunning.
+func (r *Rerunner) statuses() []string {
 	statuses := []string{"failure"}
 	if r.opts.IncludeCancelled {
 		statuses = append(statuses, "cancelled")
@@ -352,17 +655,112 @@ func (r *Rerunner) fetchRunsForContextParallel() ([]gh.WorkflowRun, error) {
 	if r.opts.IncludeTimedOut {
 		statuses = append(statuses, "timed_out")
 	}
+	return statuses
+}
+
+// runFilters expands the selected statuses and workflows into one server-side
+// query each. Workflows are only pushed to the server when every --workflow
+// selector maps onto the per-workflow endpoint; globs and names are matched
+// client-side by filterRuns instead.
+func (r *Rerunner) runFilters(branch string) []gh.RunFilter {
+	workflows := []string{""}
+	if len(r.include) > 0 {
+		var ids []string
+		for _, w := range r.include {
+			id := w.serverSide()
+			if id == "" {
+				ids = nil
+				break
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			workflows = ids
+		}
+	}
+
+	var filters []gh.RunFilter
+	for _, status := range r.statuses() {
+		for _, wf := range workflows {
+			filters = append(filters, gh.RunFilter{
+				Branch:   branch,
+				Status:   status,
+				Workflow: wf,
+				Event:    r.opts.Event,
+
+				CreatedAfter:  r.since,
+				CreatedBefore: r.until,
+			})
+		}
+	}
+	return filters
+}
+
+// filterRuns applies the client-side selection rules to discovered runs.
+func (r *Rerunner) filterRuns(runs []gh.WorkflowRun) []gh.WorkflowRun {
+	if !r.hasRunFilters() {
+		return runs
+	}
+
+	var kept []gh.WorkflowRun
+	for _, run := range runs {
+		// Runs named explicitly (URLs, stdin) bypass the server-side filters.
+		if r.matchesRun(run) {
+			kept = append(kept, run)
+		}
+	}
+
+	if dropped := len(runs) - len(kept); dropped > 0 {
+		fmt.Printf("Filtered out %d runs by workflow, event, actor or head repository\n", dropped)
+	}
+	return kept
+}
+
+// hasRunFilters reports whether any filter is applied client-side.
+func (r *Rerunner) hasRunFilters() bool {
+	return len(r.include) > 0 || len(r.exclude) > 0 || r.opts.Event != "" || r.actor != "" || r.headRepo != "" ||
+		!r.settledAfter.IsZero()
+}
+
+// matchesRun applies the client-side run filters: workflow selectors, event,
+// actor, the fork head repository and serve's completion window. The actor is only checked here: the
+// API's actor parameter matches the run's creator alone, which would drop
+// reruns triggered by the user.
+func (r *Rerunner) matchesRun(run gh.WorkflowRun) bool {
+	if r.opts.Event != "" && run.Event != r.opts.Event {
+		return false
+	}
+	if r.actor != "" && !strings.EqualFold(run.Actor.Login, r.actor) && !strings.EqualFold(run.TriggeringActor.Login, r.actor) {
+		return false
+	}
+	if len(r.include) > 0 && !matchesAnyWorkflow(r.include, run) {
+		return false
+	}
+	if matchesAnyWorkflow(r.exclude, run) {
+		return false
+	}
+	if r.headRepo != "" && !strings.EqualFold(run.HeadRepository.FullName, r.headRepo) {
+		return false
+	}
+	if !r.settledAfter.IsZero() && run.UpdatedAt.Before(r.settledAfter) {
+		return false
+	}
+	return true
+}
+
+func (r *Rerunner) fetchRunsForContextParallel() ([]gh.WorkflowRun, error) {
+	filters := r.runFilters(r.opts.Branch)
 
 	var allRuns []gh.WorkflowRun
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(statuses))
+	errChan := make(chan error, len(filters))
 
-	for _, status := range statuses {
+	for _, filter := range filters {
 		wg.Add(1)
-		go func(s string) {
+		go func(f gh.RunFilter) {
 			defer wg.Done()
-			runs, err := r.client.FetchWorkflowRuns(r.opts.Branch, s, sinceTime, r.opts.Limit)
+			runs, err := r.collectRuns(f, r.opts.Limit, r.matchesRun)
 			if err != nil {
 				errChan <- err
 				return
@@ -370,7 +768,7 @@ func (r *Rerunner) fetchRunsForContextParallel() ([]gh.WorkflowRun, error) {
 			mu.Lock()
 			allRuns = append(allRuns, runs...)
 			mu.Unlock()
-		}(status)
+		}(filter)
 	}
 
 	wg.Wait()
@@ -390,41 +788,72 @@ func (r *Rerunner) fetchFailedRunsForSha(sha string) ([]gh.WorkflowRun, error) {
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	statuses := []string{"failure"}
-	if r.opts.IncludeCancelled {
-		statuses = append(statuses, "cancelled")
-	}
-	if r.opts.IncludeTimedOut {
-		statuses = append(statuses, "timed_out")
-	}
-
-	for _, status := range statuses {
+	for _, filter := range r.runFilters("") {
+		filter.HeadSha = sha
 		wg.Add(1)
-		go func(s string) {
+		go func(f gh.RunFilter) {
 			defer wg.Done()
-			runs, err := r.client.FetchWorkflowRunsForSha(sha, s, r.opts.Limit)
+			runs, err := r.collectRuns(f, r.opts.Limit, r.matchesRun)
 			if err != nil {
-				fmt.Printf("Warning: failed to fetch %s runs for sha %s: %v\n", s, sha, err)
+				fmt.Printf("Warning: failed to fetch %s runs for sha %s: %v\n", f.Status, sha, err)
 				return
 			}
 			mu.Lock()
 			allRuns = append(allRuns, runs...)
 			mu.Unlock()
-		}(status)
+		}(filter)
 	}
 	wg.Wait()
 
-	// Filter by since if needed
-	if r.opts.Since > 0 {
-		sinceTime := time.Now().Add(-r.opts.Since)
-		var filtered []gh.WorkflowRun
-		for _, run := range allRuns {
-			if run.CreatedAt.After(sinceTime) {
-				filtered = append(filtered, run)
-			}
+	return allRuns, nil
+}
+
+// collectRuns consumes the runs iterator for filter, newest first, until it
+// crosses filter.CreatedAfter or has limit runs that pass keep (nil keeps
+// every run), so client-side filters do not eat into --limit. The created
+// window is applied by the server already; the checks here only guard against
+// stragglers. A truncated result set is reported as a warning rather than
+// silently dropping older runs.
+func (r *Rerunner) collectRuns(filter gh.RunFilter, limit int, keep func(gh.WorkflowRun) bool) ([]gh.WorkflowRun, error) {
+	var runs []gh.WorkflowRun
+	for run, err := range r.client.WorkflowRuns(filter) {
+		if errors.Is(err, gh.ErrTruncated) {
+			fmt.Printf("Warning: %s runs query incomplete, older runs may be missing: %v\n", describeFilter(filter), err)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !filter.CreatedAfter.IsZero() && run.CreatedAt.Before(filter.CreatedAfter) {
+			break
+		}
+		if !filter.CreatedBefore.IsZero() && run.CreatedAt.After(filter.CreatedBefore) {
+			continue
+		}
+		if keep != nil && !keep(run) {
+			continue
+		}
+		runs = append(runs, run)
+		if limit > 0 && len(runs) >= limit {
+			break
 		}
-		return filtered, nil
 	}
+	return runs, nil
+}
 
-	return allRuns, nil
+func describeFilter(f gh.RunFilter) string {
+	var parts []string
+	if f.Status != "" {
+		parts = append(parts, f.Status)
+	}
+	if f.Workflow != "" {
+		parts = append(parts, "workflow "+f.Workflow)
+	}
+	if f.Branch != "" {
+		parts = append(parts, "branch "+f.Branch)
+	}
+	if f.HeadSha != "" {
+		parts = append(parts, "commit "+gh.ShortSha(f.HeadSha))
+	}
+	return strings.Join(parts, ", ")
 }
@@ -1,6 +1,10 @@
 package rerunner
 
 import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,15 +14,18 @@ import (
 
 type mockGHClient struct {
 	gh.GHClient
-	fetchWorkflowRunsFunc       func(branch string, status string, since time.Time, limit int) ([]gh.WorkflowRun, error)
+	fetchWorkflowRunsFunc       func(filter gh.RunFilter) ([]gh.WorkflowRun, error)
 	rerunWorkflowFunc           func(runID int64, failedOnly bool) error
 	fetchPullRequestFunc        func(number int) (*gh.PullRequest, error)
 	fetchOpenPullRequestsFunc   func() ([]gh.PullRequest, error)
-	fetchWorkflowRunsForShaFunc func(sha string, status string, limit int) ([]gh.WorkflowRun, error)
+	fetchWorkflowRunsForShaFunc func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error)
 	fetchCommitsFunc            func(branch string, limit int) ([]gh.Commit, error)
 	fetchCommitFunc             func(sha string) (*gh.Commit, error)
+	fetchCommitRangeFunc        func(base, head string) ([]gh.Commit, error)
 	fetchWorkflowRunJobsFunc    func(runID int64) ([]gh.WorkflowJob, error)
+	fetchWorkflowRunFunc        func(runID int64) (*gh.WorkflowRun, error)
 	getRateLimitFunc            func() (*gh.RateLimit, error)
+	currentUserFunc             func() (*gh.User, error)
 }
 
 func (m *mockGHClient) FetchCommit(sha string) (*gh.Commit, error) {
@@ -28,6 +35,10 @@ func (m *mockGHClient) FetchCommit(sha string) (*gh.Commit, error) {
 	return &gh.Commit{}, nil
 }
 
+func (m *mockGHClient) FetchCommitRange(base, head string) ([]gh.Commit, error) {
+	return m.fetchCommitRangeFunc(base, head)
+}
+
 func (m *mockGHClient) FetchWorkflowRunJobs(runID int64) ([]gh.WorkflowJob, error) {
 	if m.fetchWorkflowRunJobsFunc != nil {
 		return m.fetchWorkflowRunJobsFunc(runID)
@@ -49,24 +60,61 @@ func (m *mockGHClient) GetRateLimit() (*gh.RateLimit, error) {
 	return &gh.RateLimit{Limit: 5000, Remaining: 4999, Reset: time.Now().Unix()}, nil
 }
 
-func (m *mockGHClient) FetchWorkflowRuns(branch string, status string, since time.Time, limit int) ([]gh.WorkflowRun, error) {
-	return m.fetchWorkflowRunsFunc(branch, status, since, limit)
+func (m *mockGHClient) CurrentUser() (*gh.User, error) {
+	return m.currentUserFunc()
+}
+
+func (m *mockGHClient) WorkflowRuns(filter gh.RunFilter) iter.Seq2[gh.WorkflowRun, error] {
+	return func(yield func(gh.WorkflowRun, error) bool) {
+		var runs []gh.WorkflowRun
+		var err error
+		if filter.HeadSha != "" {
+			if m.fetchWorkflowRunsForShaFunc != nil {
+				runs, err = m.fetchWorkflowRunsForShaFunc(filter.HeadSha, filter)
+			}
+		} else if m.fetchWorkflowRunsFunc != nil {
+			runs, err = m.fetchWorkflowRunsFunc(filter)
+		}
+		if err != nil {
+			yield(gh.WorkflowRun{}, err)
+			return
+		}
+		for _, run := range runs {
+			if !yield(run, nil) {
+				return
+			}
+		}
+	}
 }
 
 func (m *mockGHClient) RerunWorkflow(runID int64, failedOnly bool) error {
 	return m.rerunWorkflowFunc(runID, failedOnly)
 }
 
-func (m *mockGHClient) FetchPullRequest(number int) (*gh.PullRequest, error) {
-	return m.fetchPullRequestFunc(number)
+func (m *mockGHClient) FetchWorkflowRun(runID int64) (*gh.WorkflowRun, error) {
+	if m.fetchWorkflowRunFunc != nil {
+		return m.fetchWorkflowRunFunc(runID)
+	}
+	return &gh.WorkflowRun{ID: runID, Status: "completed"}, nil
 }
 
-func (m *mockGHClient) FetchOpenPullRequests() ([]gh.PullRequest, error) {
-	return m.fetchOpenPullRequestsFunc()
+func (m *mockGHClient) FetchPullRequest(number int) (*gh.PullRequest, error) {
+	return m.fetchPullRequestFunc(number)
 }
 
-func (m *mockGHClient) FetchWorkflowRunsForSha(sha string, status string, limit int) ([]gh.WorkflowRun, error) {
-	return m.fetchWorkflowRunsForShaFunc(sha, status, limit)
+func (m *mockGHClient) OpenPullRequests() iter.Seq2[gh.PullRequest, error] {
+	return func(yield func(gh.PullRequest, error) bool) {
+		prs, err := m.fetchOpenPullRequestsFunc()
+		if err != nil {
+			yield(gh.PullRequest{}, err)
+			return
+		}
+		for _, pr := range prs {
+			if !yield(pr, nil) {
+				return
+			}
+		}
+	}
 }
 
 func (m *mockGHClient) Repo() repository.Repository {
@@ -79,8 +127,8 @@ func (m *mockGHClient) Repo() repository.Repository {
 
 func TestRerunner_Run_FetchRunsForContext(t *testing.T) {
 	mock := &mockGHClient{
-		fetchWorkflowRunsFunc: func(branch string, status string, since time.Time, limit int) ([]gh.WorkflowRun, error) {
-			if status == "failure" {
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status == "failure" {
 				return []gh.WorkflowRun{
 					{ID: 1, Name: "Workflow 1", CreatedAt: time.Now()},
 				}, nil
@@ -107,8 +155,8 @@ func TestRerunner_Run_FetchRunsForContext(t *testing.T) {
 
 func TestRerunner_Run_Limit(t *testing.T) {
 	mock := &mockGHClient{
-		fetchWorkflowRunsFunc: func(branch string, status string, since time.Time, limit int) ([]gh.WorkflowRun, error) {
-			if status == "failure" {
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status == "failure" {
 				return []gh.WorkflowRun{
 					{ID: 1, Name: "Workflow 1", CreatedAt: time.Now()},
 					{ID: 2, Name: "Workflow 2", CreatedAt: time.Now()},
@@ -136,3 +184,253 @@ func TestRerunner_Run_Limit(t *testing.T) {
 	}
 	// We'd need to track calls to verify limit, but the output will show it.
 }
+
+func TestRerunner_Run_LimitAfterWorkflowFilter(t *testing.T) {
+	var rerun []int64
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			// The newer Lint failure must not use up the limit of one.
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "Lint", HeadSha: "aaa", CreatedAt: time.Now()},
+				{ID: 2, Name: "Build", HeadSha: "bbb", CreatedAt: time.Now().Add(-time.Hour)},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			rerun = append(rerun, runID)
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Workflows: []string{"Build"}, Limit: 1})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rerun) != 1 || rerun[0] != 2 {
+		t.Errorf("Expected the Build run to be rerun, got %v", rerun)
+	}
+}
+
+func TestRerunner_Run_Commit(t *testing.T) {
+	fullSha := "8f2a1c0d9e8b7a6f5e4d3c2b1a0f9e8d7c6b5a49"
+	var queriedSha string
+	mock := &mockGHClient{
+		fetchCommitFunc: func(sha string) (*gh.Commit, error) {
+			if sha == "8f2a1" {
+				return &gh.Commit{SHA: fullSha}, nil
+			}
+			return &gh.Commit{}, nil
+		},
+		fetchWorkflowRunsForShaFunc: func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			queriedSha = sha
+			return nil, nil
+		},
+	}
+
+	opts := Options{
+		Repo:   "owner/repo",
+		Commit: "8f2a1",
+		DryRun: true,
+	}
+
+	r := NewRerunner(mock, opts)
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if queriedSha != fullSha {
+		t.Errorf("Expected runs to be fetched for %s, got %q", fullSha, queriedSha)
+	}
+}
+
+func TestRerunner_Run_Event(t *testing.T) {
+	var rerun []int64
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Event != "schedule" {
+				t.Errorf("Expected event filter schedule, got %q", filter.Event)
+			}
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			// The second run would only show up if the server ignored the filter.
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "Nightly", Event: "schedule", CreatedAt: time.Now()},
+				{ID: 2, Name: "CI", Event: "pull_request", CreatedAt: time.Now()},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			rerun = append(rerun, runID)
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Event: "schedule"})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rerun) != 1 || rerun[0] != 1 {
+		t.Errorf("Expected only the scheduled run to be rerun, got %v", rerun)
+	}
+}
+
+func TestRerunner_Run_Mine(t *testing.T) {
+	var mu sync.Mutex
+	var scanned []string
+	var rerun []int64
+	mock := &mockGHClient{
+		currentUserFunc: func() (*gh.User, error) {
+			return &gh.User{Login: "octocat"}, nil
+		},
+		fetchOpenPullRequestsFunc: func() ([]gh.PullRequest, error) {
+			return []gh.PullRequest{
+				{Number: 1, HeadRefOid: "mine", Author: gh.User{Login: "octocat"}},
+				{Number: 2, HeadRefOid: "theirs", Author: gh.User{Login: "hubot"}},
+			}, nil
+		},
+		fetchWorkflowRunsForShaFunc: func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			mu.Lock()
+			scanned = append(scanned, sha)
+			mu.Unlock()
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			// Run 1 was created by hubot but rerun by octocat, which the
+			// server-side actor parameter would not match.
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "CI", HeadSha: sha, CreatedAt: time.Now(),
+					Actor: gh.User{Login: "hubot"}, TriggeringActor: gh.User{Login: "octocat"}},
+				{ID: 2, Name: "Lint", HeadSha: sha, CreatedAt: time.Now(),
+					Actor: gh.User{Login: "hubot"}, TriggeringActor: gh.User{Login: "hubot"}},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			mu.Lock()
+			rerun = append(rerun, runID)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", AllOpenPRs: true, Mine: true})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rerun) != 1 || rerun[0] != 1 {
+		t.Errorf("Expected only the run triggered by octocat to be rerun, got %v", rerun)
+	}
+	for _, sha := range scanned {
+		if sha != "mine" {
+			t.Errorf("Expected only octocat's PR to be scanned, got %v", scanned)
+			break
+		}
+	}
+}
+
+func TestRerunner_Run_MergeQueue(t *testing.T) {
+	var mu sync.Mutex
+	var rerun []int64
+	mock := &mockGHClient{
+		fetchPullRequestFunc: func(number int) (*gh.PullRequest, error) {
+			return &gh.PullRequest{
+				Number:     number,
+				HeadRefOid: "head",
+				MergeQueueEntry: &gh.MergeQueueEntry{
+					State:      "UNMERGEABLE",
+					Position:   1,
+					HeadCommit: gh.GitObject{Oid: "queue"},
+				},
+			}, nil
+		},
+		fetchWorkflowRunsForShaFunc: func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if sha != "queue" || filter.Status != "failure" {
+				return nil, nil
+			}
+			return []gh.WorkflowRun{
+				{ID: 7, Name: "CI", Event: "merge_group", HeadBranch: "gh-readonly-queue/main/pr-5-abc", HeadSha: "queue"},
+				{ID: 8, Name: "Other", Event: "push", HeadSha: "queue"},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			mu.Lock()
+			rerun = append(rerun, runID)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", PRNumber: 5})
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rerun) != 1 || rerun[0] != 7 {
+		t.Errorf("Expected the merge_group run to be rerun, got %v", rerun)
+	}
+}
+
+func TestRerunner_Run_AllPRsListError(t *testing.T) {
+	mock := &mockGHClient{
+		fetchOpenPullRequestsFunc: func() ([]gh.PullRequest, error) {
+			return nil, errors.New("boom")
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", AllOpenPRs: true})
+	if err := r.Run(); err == nil {
+		t.Fatal("Expected the PR listing error to be returned")
+	}
+}
+
+type seqClient struct {
+	mockGHClient
+	runs     []gh.WorkflowRun
+	trailing error
+	consumed int
+}
+
+func (c *seqClient) WorkflowRuns(filter gh.RunFilter) iter.Seq2[gh.WorkflowRun, error] {
+	return func(yield func(gh.WorkflowRun, error) bool) {
+		for _, run := range c.runs {
+			c.consumed++
+			if !yield(run, nil) {
+				return
+			}
+		}
+		if c.trailing != nil {
+			yield(gh.WorkflowRun{}, c.trailing)
+		}
+	}
+}
+
+func TestRerunner_CollectRuns(t *testing.T) {
+	now := time.Now()
+	client := &seqClient{runs: []gh.WorkflowRun{
+		{ID: 1, CreatedAt: now},
+		{ID: 2, CreatedAt: now.Add(-time.Hour)},
+		{ID: 3, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: 4, CreatedAt: now.Add(-4 * time.Hour)},
+	}}
+	r := NewRerunner(client, Options{})
+
+	runs, err := r.collectRuns(gh.RunFilter{CreatedAfter: now.Add(-2 * time.Hour)}, 0, nil)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Expected 2 runs inside the since window, got %d (%v)", len(runs), err)
+	}
+	if client.consumed != 3 {
+		t.Errorf("Expected iteration to stop at the since boundary, consumed %d runs", client.consumed)
+	}
+
+	client.consumed = 0
+	client.trailing = fmt.Errorf("%w: stopped after 100 pages", gh.ErrTruncated)
+	runs, err = r.collectRuns(gh.RunFilter{}, 0, nil)
+	if err != nil || len(runs) != 4 {
+		t.Errorf("Expected truncation to keep the 4 runs seen so far, got %d (%v)", len(runs), err)
+	}
+
+	client.trailing = nil
+	runs, err = r.collectRuns(gh.RunFilter{CreatedBefore: now.Add(-30 * time.Minute)}, 0, nil)
+	if err != nil || len(runs) != 3 || runs[0].ID != 2 {
+		t.Errorf("Expected runs newer than --until to be skipped, got %d (%v)", len(runs), err)
+	}
+}
@@ -0,0 +1,105 @@
+package rerunner
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryBudget = 3
+	defaultRetryDelay  = 2 * time.Minute
+)
+
+// retryBudget is the maximum number of reruns per run in --until-green mode,
+// counting the initial rerun.
+func (r *Rerunner) retryBudget() int {
+	if r.opts.Retries > 0 {
+		return r.opts.Retries
+	}
+	return defaultRetryBudget
+}
+
+func (r *Rerunner) retryDelay() time.Duration {
+	if r.opts.RetryDelay > 0 {
+		return r.opts.RetryDelay
+	}
+	return defaultRetryDelay
+}
+
+// backoff returns the wait before the next rerun of a run that has already
+// been rerun n times: the base delay, doubled for every rerun after the first.
+func (r *Rerunner) backoff(n int) time.Duration {
+	return r.retryDelay() << max(n-1, 0)
+}
+
+// scheduleRetries drives --until-green. A completed red attempt gets a retry
+// scheduled after an exponential backoff, together with the names of its
+// still-failing jobs; once the retry is due, only those failed jobs are
+// rerun. Runs out of budget, or at the --max-attempts cap, are given up on.
+func (r *Rerunner) scheduleRetries(watched []*watchedRun, now time.Time) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, w := range watched {
+		if !w.done() || w.passed() || w.gaveUp {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(w *watchedRun) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if w.retryAt.IsZero() {
+				r.planRetry(w, now)
+				return
+			}
+			if now.Before(w.retryAt) {
+				return
+			}
+
+			w.retryAt = time.Time{}
+			if err := r.client.RerunWorkflow(w.run.ID, true); err != nil {
+				w.err = fmt.Errorf("retry failed: %w", err)
+				w.gaveUp = true
+				return
+			}
+			w.fromAttempt = w.run.RunAttempt
+			w.reruns++
+		}(w)
+	}
+	wg.Wait()
+}
+
+// planRetry records the failing jobs of a red attempt and schedules the next
+// rerun, or gives up when the budget or attempt cap is exhausted.
+func (r *Rerunner) planRetry(w *watchedRun, now time.Time) {
+	jobs, err := r.client.FetchWorkflowRunJobs(w.run.ID)
+	if err != nil {
+		w.err = fmt.Errorf("could not fetch jobs: %w", err)
+	} else {
+		w.failedJobs = w.failedJobs[:0]
+		for _, job := range jobs {
+			if job.Failed() {
+				w.failedJobs = append(w.failedJobs, job.Name)
+			}
+		}
+	}
+
+	switch {
+	case w.reruns >= r.retryBudget():
+		w.gaveUp = true
+	case r.opts.MaxAttempts > 0 && w.run.RunAttempt >= r.opts.MaxAttempts:
+		w.gaveUp = true
+	default:
+		w.retryAt = now.Add(r.backoff(w.reruns))
+	}
+}
+
+func describeDeadline(deadline time.Time) string {
+	if deadline.IsZero() {
+		return ""
+	}
+	return ", deadline " + deadline.Format("15:04:05")
+}
@@ -0,0 +1,124 @@
+package rerunner
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// flakyRuns simulates GitHub for --until-green: every rerun starts a new
+// attempt, which completes with the next scripted conclusion on the
+// following poll.
+type flakyRuns struct {
+	mu       sync.Mutex
+	attempt  map[int64]int
+	outcomes map[int64][]string
+	reruns   map[int64][]bool
+}
+
+func (f *flakyRuns) client() *mockGHClient {
+	return &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			var runs []gh.WorkflowRun
+			for id := range f.outcomes {
+				runs = append(runs, gh.WorkflowRun{ID: id, Name: "CI", HeadSha: fmt.Sprint(id), RunAttempt: 1, Status: "completed", Conclusion: "failure", CreatedAt: time.Now()})
+			}
+			return runs, nil
+		},
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			attempt := f.attempt[runID]
+			run := &gh.WorkflowRun{ID: runID, Name: "CI", RunAttempt: attempt, Status: "completed", Conclusion: "failure"}
+			if attempt > 1 {
+				run.Conclusion = f.outcomes[runID][attempt-2]
+			}
+			return run, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.attempt[runID]++
+			f.reruns[runID] = append(f.reruns[runID], failedOnly)
+			return nil
+		},
+		fetchWorkflowRunJobsFunc: func(runID int64) ([]gh.WorkflowJob, error) {
+			return []gh.WorkflowJob{
+				{Name: "build", Conclusion: "success"},
+				{Name: "test (ubuntu)", Conclusion: "failure"},
+			}, nil
+		},
+	}
+}
+
+func TestRerunner_Run_UntilGreen(t *testing.T) {
+	f := &flakyRuns{
+		attempt: map[int64]int{1: 1, 2: 1},
+		outcomes: map[int64][]string{
+			1: {"failure", "success"},
+			2: {"failure", "failure", "failure"},
+		},
+		reruns: map[int64][]bool{},
+	}
+
+	r := NewRerunner(f.client(), Options{
+		Repo:          "owner/repo",
+		UntilGreen:    true,
+		Retries:       2,
+		RetryDelay:    time.Millisecond,
+		WatchInterval: time.Millisecond,
+	})
+	if err := r.Run(); err == nil {
+		t.Fatal("Expected an error because run 2 is still failing")
+	}
+
+	// The initial rerun uses the configured mode; retries only rerun failed jobs.
+	if got := f.reruns[1]; len(got) != 2 || got[0] || !got[1] {
+		t.Errorf("Expected run 1 to be rerun, then retried once with failed jobs only, got %v", got)
+	}
+	if got := f.reruns[2]; len(got) != 2 {
+		t.Errorf("Expected run 2 to stop at the budget of 2 reruns, got %v", got)
+	}
+}
+
+func TestRerunner_Run_UntilGreenDeadline(t *testing.T) {
+	f := &flakyRuns{
+		attempt:  map[int64]int{1: 1},
+		outcomes: map[int64][]string{1: {"failure", "failure", "failure", "failure"}},
+		reruns:   map[int64][]bool{},
+	}
+
+	r := NewRerunner(f.client(), Options{
+		Repo:          "owner/repo",
+		UntilGreen:    true,
+		Retries:       5,
+		RetryDelay:    time.Hour,
+		Deadline:      10 * time.Millisecond,
+		WatchInterval: time.Millisecond,
+	})
+	start := time.Now()
+	if err := r.Run(); err == nil {
+		t.Fatal("Expected an error because the run never went green")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the deadline to cut the backoff short, took %s", elapsed)
+	}
+	if got := f.reruns[1]; len(got) != 1 {
+		t.Errorf("Expected no retry before the deadline, got %v", got)
+	}
+}
+
+func TestRerunner_Backoff(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{RetryDelay: 2 * time.Minute})
+	for n, want := range []time.Duration{2 * time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute} {
+		if got := r.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
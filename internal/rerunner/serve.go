@@ -0,0 +1,186 @@
+package rerunner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+const (
+	defaultServeInterval = 10 * time.Minute
+
+	// retriedRetention bounds the memory of retried attempts. GitHub refuses
+	// reruns of runs older than a month, so older entries can never matter.
+	retriedRetention = 30 * 24 * time.Hour
+
+	// serveLookback is the created window Serve scans without --since. A run
+	// keeps its created_at when it is rerun, so the window has to cover long
+	// runs and late reruns; which runs are new is decided by completion time.
+	serveLookback = 7 * 24 * time.Hour
+)
+
+// runAttempt identifies one attempt of a run. Serve never reruns the same
+// attempt twice; a later attempt that fails again is a new candidate, subject
+// to --max-attempts.
+type runAttempt struct {
+	ID      int64 `json:"id"`
+	Attempt int   `json:"attempt"`
+}
+
+// retriedEntry is the state file representation of one retried attempt.
+type retriedEntry struct {
+	runAttempt
+	RetriedAt time.Time `json:"retried_at"`
+}
+
+// Serve repeats discovery every Interval until ctx is cancelled. Each cycle
+// evaluates --since afresh, so a relative window slides along with the clock.
+// Without --since, runs created in the last serveLookback are scanned and
+// those that finished (were last updated) within twice the interval are
+// candidates, which lets consecutive cycles overlap. Retried attempts are remembered (and persisted to StateFile
+// when set) so no attempt is ever triggered twice. Cancellation is only
+// checked between cycles, so a cycle that has started triggering always
+// finishes.
+func (r *Rerunner) Serve(ctx context.Context) error {
+	if r.opts.Watch || r.opts.UntilGreen {
+		return errors.New("--watch and --until-green cannot be combined with serve")
+	}
+
+	interval := r.opts.Interval
+	if interval <= 0 {
+		interval = defaultServeInterval
+	}
+	window := "since " + r.opts.Since
+	if r.opts.Since == "" {
+		r.opts.Since = serveLookback.String()
+		r.settledWindow = 2 * interval
+		window = "that finished in the last " + r.settledWindow.String()
+	}
+
+	r.retried = make(map[runAttempt]time.Time)
+	if err := r.loadRetried(); err != nil {
+		return err
+	}
+
+	logf("Serving: checking every %s for failed runs %s (%d retried attempts remembered)",
+		interval, window, len(r.retried))
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for cycle := 1; ; cycle++ {
+		select {
+		case <-ctx.Done():
+			logf("Shutting down after %d cycles", cycle-1)
+			return nil
+		case <-timer.C:
+		}
+
+		logf("Cycle %d: starting discovery", cycle)
+		sum, err := r.run()
+		if err != nil {
+			logf("Cycle %d failed: %v", cycle, err)
+		} else {
+			r.logDecisions(sum)
+			r.remember(sum, time.Now())
+			if err := r.saveRetried(); err != nil {
+				logf("Warning: could not save state to %s: %v", r.opts.StateFile, err)
+			}
+		}
+		logf("Cycle %d done; next in %s", cycle, interval)
+		timer.Reset(interval)
+	}
+}
+
+// skipRetried drops the attempts Serve has already rerun.
+func (r *Rerunner) skipRetried(runs []gh.WorkflowRun) ([]gh.WorkflowRun, []skippedRun) {
+	var kept []gh.WorkflowRun
+	var skipped []skippedRun
+	for _, run := range runs {
+		if at, ok := r.retried[runAttempt{ID: run.ID, Attempt: run.RunAttempt}]; ok {
+			skipped = append(skipped, skippedRun{run: run, reason: "already retried at " + at.Format(time.RFC3339)})
+			continue
+		}
+		kept = append(kept, run)
+	}
+	return kept, skipped
+}
+
+func (r *Rerunner) remember(sum *summary, now time.Time) {
+	for _, run := range sum.triggered {
+		r.retried[runAttempt{ID: run.ID, Attempt: run.RunAttempt}] = now
+	}
+	for key, at := range r.retried {
+		if now.Sub(at) > retriedRetention {
+			delete(r.retried, key)
+		}
+	}
+}
+
+// logDecisions writes one timestamped line per candidate of a cycle.
+func (r *Rerunner) logDecisions(sum *summary) {
+	for _, run := range sum.triggered {
+		logf("rerun %s %s", describeRun(run), run.HTMLURL)
+	}
+	for _, run := range sum.failed {
+		logf("failed to rerun %s %s", describeRun(run), run.HTMLURL)
+	}
+	for _, sk := range sum.skipped {
+		logf("skip %s: %s", describeRun(sk.run), sk.reason)
+	}
+	for _, run := range sum.exhausted {
+		logf("skip %s: attempt cap %d reached", describeRun(run), r.opts.MaxAttempts)
+	}
+}
+
+func (r *Rerunner) loadRetried() error {
+	if r.opts.StateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.opts.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+
+	var entries []retriedEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parsing state file %s: %w", r.opts.StateFile, err)
+	}
+	for _, e := range entries {
+		r.retried[e.runAttempt] = e.RetriedAt
+	}
+	return nil
+}
+
+// saveRetried writes the state file atomically so a SIGTERM mid-write never
+// leaves it truncated.
+func (r *Rerunner) saveRetried() error {
+	if r.opts.StateFile == "" {
+		return nil
+	}
+	entries := make([]retriedEntry, 0, len(r.retried))
+	for key, at := range r.retried {
+		entries = append(entries, retriedEntry{runAttempt: key, RetriedAt: at})
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := r.opts.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.opts.StateFile)
+}
+
+func logf(format string, args ...any) {
+	fmt.Printf("[%s] "+format+"\n", append([]any{time.Now().Format(time.RFC3339)}, args...)...)
+}
@@ -0,0 +1,115 @@
+package rerunner
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestRerunner_Serve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Run 7 is listed as failed at attempt 1 for two cycles, then at
+	// attempt 2 once its rerun has failed too.
+	var mu sync.Mutex
+	cycles, attempt := 0, 1
+	reruns := map[runAttempt]int{}
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			if filter.CreatedAfter.IsZero() {
+				t.Error("Expected serve to apply a default since window")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			cycles++
+			if cycles == 3 {
+				attempt = 2
+				cancel()
+			}
+			return []gh.WorkflowRun{
+				{ID: 7, Name: "CI", RunAttempt: attempt, Status: "completed", Conclusion: "failure",
+					CreatedAt: time.Now(), UpdatedAt: time.Now()},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			mu.Lock()
+			defer mu.Unlock()
+			reruns[runAttempt{ID: runID, Attempt: attempt}]++
+			return nil
+		},
+	}
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Interval: time.Millisecond, StateFile: stateFile})
+	if err := r.Serve(ctx); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	if cycles != 3 {
+		t.Errorf("Expected 3 cycles before shutdown, got %d", cycles)
+	}
+	if len(reruns) != 2 || reruns[runAttempt{ID: 7, Attempt: 1}] != 1 || reruns[runAttempt{ID: 7, Attempt: 2}] != 1 {
+		t.Errorf("Expected each attempt to be rerun exactly once, got %v", reruns)
+	}
+
+	// A restarted daemon picks up the attempts the last one retried.
+	restarted := NewRerunner(mock, Options{StateFile: stateFile})
+	restarted.retried = make(map[runAttempt]time.Time)
+	if err := restarted.loadRetried(); err != nil {
+		t.Fatalf("loadRetried: %v", err)
+	}
+	if len(restarted.retried) != 2 {
+		t.Errorf("Expected 2 remembered attempts after restart, got %v", restarted.retried)
+	}
+}
+
+func TestRerunner_Serve_CompletionWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Both runs were created an hour ago, well before twice the interval.
+	// Run 1 only just failed; run 2 failed long ago.
+	created := time.Now().Add(-time.Hour)
+	var reruns []int64
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			cancel()
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "Slow", HeadSha: "aaa", RunAttempt: 1, Status: "completed", Conclusion: "failure",
+					CreatedAt: created, UpdatedAt: time.Now()},
+				{ID: 2, Name: "Old", HeadSha: "bbb", RunAttempt: 1, Status: "completed", Conclusion: "failure",
+					CreatedAt: created, UpdatedAt: created.Add(time.Minute)},
+			}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			reruns = append(reruns, runID)
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Interval: time.Minute})
+	if err := r.Serve(ctx); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if len(reruns) != 1 || reruns[0] != 1 {
+		t.Errorf("Expected only the run that failed within the window to be rerun, got %v", reruns)
+	}
+}
+
+func TestRerunner_Serve_RejectsWatch(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{Watch: true})
+	if err := r.Serve(context.Background()); err == nil {
+		t.Error("Expected serve to reject --watch")
+	}
+}
@@ -0,0 +1,130 @@
+package rerunner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// stdinArg is the positional argument that stands for "read run IDs from stdin".
+const stdinArg = "-"
+
+type runRef struct {
+	ID         int64 `json:"id"`
+	DatabaseID int64 `json:"databaseId"`
+}
+
+func (ref runRef) runID() int64 {
+	if ref.DatabaseID != 0 {
+		return ref.DatabaseID
+	}
+	return ref.ID
+}
+
+// parseRunRefs reads workflow run references from in. Input is either JSON
+// (an array or a stream of objects with an id/databaseId field, as produced by
+// `gh run list --json databaseId` or `gh api`) or one reference per line, where
+// each line is a run ID, a run URL or a single-line JSON object.
+func parseRunRefs(in io.Reader, repo repository.Repository) ([]int64, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		if ids, err := parseRunRefsJSON(trimmed); err == nil {
+			return ids, nil
+		}
+		// Fall through: may be line-oriented input that merely starts with an object.
+	}
+
+	var ids []int64
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		id, err := parseRunRefLine(line, repo)
+		if err != nil {
+			return nil, fmt.Errorf("stdin line %d: %w", lineNo, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return ids, nil
+}
+
+func parseRunRefsJSON(data []byte) ([]int64, error) {
+	var ids []int64
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var refs []runRef
+		if raw[0] == '[' {
+			if err := json.Unmarshal(raw, &refs); err != nil {
+				return nil, err
+			}
+		} else {
+			var ref runRef
+			if err := json.Unmarshal(raw, &ref); err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+
+		for _, ref := range refs {
+			if ref.runID() == 0 {
+				return nil, fmt.Errorf("JSON object without an id or databaseId field")
+			}
+			ids = append(ids, ref.runID())
+		}
+	}
+	return ids, nil
+}
+
+func parseRunRefLine(line string, repo repository.Repository) (int64, error) {
+	if strings.HasPrefix(line, "{") {
+		var ref runRef
+		if err := json.Unmarshal([]byte(line), &ref); err != nil {
+			return 0, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if ref.runID() == 0 {
+			return 0, fmt.Errorf("JSON object without an id or databaseId field")
+		}
+		return ref.runID(), nil
+	}
+
+	if strings.HasPrefix(line, "https://") || strings.HasPrefix(line, "http://") {
+		t, err := parseTargetURL(line, repo)
+		if err != nil {
+			return 0, err
+		}
+		if t.kind != targetRun {
+			return 0, fmt.Errorf("%s is not a workflow run URL", line)
+		}
+		return t.runID, nil
+	}
+
+	id, err := strconv.ParseInt(line, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid run ID %q", line)
+	}
+	return id, nil
+}
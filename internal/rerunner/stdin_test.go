@@ -0,0 +1,42 @@
+package rerunner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+func TestParseRunRefs(t *testing.T) {
+	repo := repository.Repository{Host: "github.com", Owner: "o", Name: "r"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []int64
+	}{
+		{"ids", "111\n\n222\n", []int64{111, 222}},
+		{"urls", "https://github.com/o/r/actions/runs/111\nhttps://github.com/o/r/actions/runs/222/job/9\n", []int64{111, 222}},
+		{"json array", `[{"databaseId": 111}, {"databaseId": 222}]`, []int64{111, 222}},
+		{"json stream", "{\n  \"id\": 111\n}\n{\n  \"id\": 222\n}\n", []int64{111, 222}},
+		{"mixed lines", "111\n{\"id\": 222}\nhttps://github.com/o/r/actions/runs/333\n", []int64{111, 222, 333}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseRunRefs(strings.NewReader(tt.input), repo)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"abc", `[{"name": "CI"}]`, "https://github.com/o/r/pull/1"} {
+		if _, err := parseRunRefs(strings.NewReader(input), repo); err == nil {
+			t.Errorf("parseRunRefs(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rerunner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// skippedRun is a candidate we deliberately left alone, with the reason shown
+// to the user.
+type skippedRun struct {
+	run    gh.WorkflowRun
+	reason string
+}
+
+// summary collects what happened to every candidate run so a single report
+// can be printed at the end. The add* methods are safe for concurrent use.
+type summary struct {
+	mu        sync.Mutex
+	triggered []gh.WorkflowRun
+	failed    []gh.WorkflowRun
+	skipped   []skippedRun
+	exhausted []gh.WorkflowRun
+}
+
+func (s *summary) addTriggered(run gh.WorkflowRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.triggered = append(s.triggered, run)
+}
+
+func (s *summary) addFailed(run gh.WorkflowRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, run)
+}
+
+func (s *summary) addSkipped(skipped ...skippedRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped = append(s.skipped, skipped...)
+}
+
+func (s *summary) print(dryRun bool, maxAttempts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.skipped) > 0 {
+		fmt.Printf("Skipped %d runs:\n", len(s.skipped))
+		for _, sk := range s.skipped {
+			fmt.Printf("  - %s: %s\n", describeRun(sk.run), sk.reason)
+		}
+	}
+
+	if len(s.exhausted) > 0 {
+		fmt.Printf("⚠ %d runs exhausted (attempt cap %d reached) and need a human look:\n", len(s.exhausted), maxAttempts)
+		for _, run := range s.exhausted {
+			fmt.Printf("  - %s %s\n", describeRun(run), run.HTMLURL)
+		}
+	}
+
+	if dryRun {
+		return
+	}
+	fmt.Printf("Summary: %d triggered, %d failed, %d skipped, %d exhausted\n",
+		len(s.triggered), len(s.failed), len(s.skipped), len(s.exhausted))
+}
+
+func describeRun(run gh.WorkflowRun) string {
+	return fmt.Sprintf("%s (%s@%s) | #%d (attempt %d)",
+		run.Name, run.HeadBranch, gh.ShortSha(run.HeadSha), run.RunNumber, run.RunAttempt)
+}
@@ -0,0 +1,221 @@
+package rerunner
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+type targetKind int
+
+const (
+	targetPR targetKind = iota
+	targetRun
+	targetCommit
+)
+
+// target is a single positional argument classified into something we know
+// how to turn into workflow runs.
+type target struct {
+	kind   targetKind
+	number int    // PR number
+	runID  int64  // workflow run ID
+	ref    string // commit SHA or ref
+}
+
+// PR numbers are short; longer all-digit strings are far more likely to be
+// short SHAs that happen to contain no letters.
+var prNumberRe = regexp.MustCompile(`^#?[0-9]{1,6}$`)
+
+func parseTarget(arg string, repo repository.Repository) (target, error) {
+	if prNumberRe.MatchString(arg) {
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil || n <= 0 {
+			return target{}, fmt.Errorf("invalid PR number %q", arg)
+		}
+		return target{kind: targetPR, number: n}, nil
+	}
+
+	if strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://") {
+		return parseTargetURL(arg, repo)
+	}
+
+	return target{kind: targetCommit, ref: arg}, nil
+}
+
+// parseTargetURL understands pull request, workflow run and commit URLs, e.g.
+// https://github.com/o/r/pull/789, https://github.com/o/r/actions/runs/111
+// (optionally followed by /job/... or /attempts/...) and
+// https://github.com/o/r/commit/abc1234.
+func parseTargetURL(arg string, repo repository.Repository) (target, error) {
+	u, err := url.Parse(arg)
+	if err != nil {
+		return target{}, fmt.Errorf("invalid URL %q: %w", arg, err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 {
+		return target{}, fmt.Errorf("unrecognized GitHub URL %q", arg)
+	}
+
+	owner, name := parts[0], parts[1]
+	if !strings.EqualFold(owner, repo.Owner) || !strings.EqualFold(name, repo.Name) {
+		return target{}, fmt.Errorf("%s belongs to %s/%s, not the targeted repository %s/%s",
+			arg, owner, name, repo.Owner, repo.Name)
+	}
+
+	switch {
+	case parts[2] == "pull":
+		n, err := strconv.Atoi(parts[3])
+		if err != nil || n <= 0 {
+			return target{}, fmt.Errorf("invalid PR number in %q", arg)
+		}
+		return target{kind: targetPR, number: n}, nil
+	case parts[2] == "actions" && len(parts) >= 5 && parts[3] == "runs":
+		id, err := strconv.ParseInt(parts[4], 10, 64)
+		if err != nil || id <= 0 {
+			return target{}, fmt.Errorf("invalid run ID in %q", arg)
+		}
+		return target{kind: targetRun, runID: id}, nil
+	case parts[2] == "commit":
+		return target{kind: targetCommit, ref: parts[3]}, nil
+	}
+
+	return target{}, fmt.Errorf("unrecognized GitHub URL %q", arg)
+}
+
+// collectTargets gathers the positional arguments together with --pr and
+// --commit, which are just single-target shorthands. A "-" argument expands to
+// the run references read from stdin.
+func (r *Rerunner) collectTargets() ([]target, error) {
+	repo := r.client.Repo()
+
+	var targets []target
+	readStdin := false
+	for _, arg := range r.opts.Targets {
+		if arg == stdinArg {
+			readStdin = true
+			continue
+		}
+		t, err := parseTarget(arg, repo)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	if r.opts.PRNumber > 0 {
+		targets = append(targets, target{kind: targetPR, number: r.opts.PRNumber})
+	}
+	if r.opts.Commit != "" {
+		targets = append(targets, target{kind: targetCommit, ref: r.opts.Commit})
+	}
+
+	if readStdin {
+		if r.opts.Stdin == nil {
+			return nil, fmt.Errorf("no stdin available to read run IDs from")
+		}
+		ids, err := parseRunRefs(r.opts.Stdin, repo)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no run IDs found on stdin")
+		}
+		fmt.Printf("Read %d run references from stdin\n", len(ids))
+		for _, id := range ids {
+			targets = append(targets, target{kind: targetRun, runID: id})
+		}
+	}
+	return targets, nil
+}
+
+func (r *Rerunner) fetchRunsForTargets(targets []target) ([]gh.WorkflowRun, error) {
+	var allRuns []gh.WorkflowRun
+	var errs []error
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var runs []gh.WorkflowRun
+			var err error
+			switch t.kind {
+			case targetPR:
+				runs, err = r.fetchRunsForPR(t.number)
+			case targetRun:
+				runs, err = r.fetchRunsByID([]int64{t.runID})
+			case targetCommit:
+				runs, err = r.fetchRunsForCommit(t.ref)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			allRuns = append(allRuns, runs...)
+		}(t)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return uniqueRuns(allRuns), nil
+}
+
+// fetchRunsByID looks up explicitly named runs. Runs that did not end in one of
+// the selected conclusions are reported and dropped, since there is nothing to
+// rerun for them.
+func (r *Rerunner) fetchRunsByID(ids []int64) ([]gh.WorkflowRun, error) {
+	wanted := make(map[string]bool)
+	for _, s := range r.statuses() {
+		wanted[s] = true
+	}
+
+	var runs []gh.WorkflowRun
+	for _, id := range ids {
+		run, err := r.client.FetchWorkflowRun(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch run %d: %w", id, err)
+		}
+		if !wanted[run.Conclusion] {
+			conclusion := run.Conclusion
+			if conclusion == "" {
+				conclusion = run.Status
+			}
+			fmt.Printf("Skipping run %d (%s): %s\n", run.ID, run.Name, conclusion)
+			continue
+		}
+		runs = append(runs, *run)
+	}
+	return runs, nil
+}
+
+// uniqueRuns drops repeated run IDs, keeping the first occurrence.
+func uniqueRuns(runs []gh.WorkflowRun) []gh.WorkflowRun {
+	seen := make(map[int64]bool, len(runs))
+	unique := runs[:0]
+	for _, run := range runs {
+		if seen[run.ID] {
+			continue
+		}
+		seen[run.ID] = true
+		unique = append(unique, run)
+	}
+	return unique
+}
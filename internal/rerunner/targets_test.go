@@ -0,0 +1,92 @@
+package rerunner
+
+import (
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestParseTarget(t *testing.T) {
+	repo := repository.Repository{Host: "github.com", Owner: "o", Name: "r"}
+
+	tests := []struct {
+		arg  string
+		want target
+	}{
+		{"123", target{kind: targetPR, number: 123}},
+		{"#45", target{kind: targetPR, number: 45}},
+		{"abc1234", target{kind: targetCommit, ref: "abc1234"}},
+		{"1234567", target{kind: targetCommit, ref: "1234567"}},
+		{"HEAD~3", target{kind: targetCommit, ref: "HEAD~3"}},
+		{"https://github.com/o/r/pull/789", target{kind: targetPR, number: 789}},
+		{"https://github.com/o/r/pull/789/files", target{kind: targetPR, number: 789}},
+		{"https://github.com/o/r/actions/runs/111", target{kind: targetRun, runID: 111}},
+		{"https://github.com/o/r/actions/runs/111/job/222", target{kind: targetRun, runID: 111}},
+		{"https://github.com/o/r/commit/abc1234", target{kind: targetCommit, ref: "abc1234"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTarget(tt.arg, repo)
+		if err != nil {
+			t.Errorf("parseTarget(%q) returned error: %v", tt.arg, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTarget(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+
+	for _, arg := range []string{
+		"https://github.com/other/repo/pull/1",
+		"https://github.com/o/r/issues/1",
+		"https://github.com/o/r",
+	} {
+		if _, err := parseTarget(arg, repo); err == nil {
+			t.Errorf("parseTarget(%q) expected an error", arg)
+		}
+	}
+}
+
+func TestRerunner_Run_MixedTargets(t *testing.T) {
+	var rerun []int64
+	mock := &mockGHClient{
+		fetchPullRequestFunc: func(number int) (*gh.PullRequest, error) {
+			return &gh.PullRequest{Number: number, HeadRefOid: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}, nil
+		},
+		fetchWorkflowRunsForShaFunc: func(sha string, filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status == "failure" {
+				return []gh.WorkflowRun{{ID: 111, Name: "CI", Conclusion: "failure"}}, nil
+			}
+			return nil, nil
+		},
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			conclusion := "failure"
+			if runID == 222 {
+				conclusion = "success"
+			}
+			return &gh.WorkflowRun{ID: runID, Name: "CI", Status: "completed", Conclusion: conclusion}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			rerun = append(rerun, runID)
+			return nil
+		},
+	}
+
+	opts := Options{
+		Repo: "owner/repo",
+		Targets: []string{
+			"12",
+			"https://github.com/owner/repo/actions/runs/111",
+			"https://github.com/owner/repo/actions/runs/222",
+		},
+	}
+
+	r := NewRerunner(mock, opts)
+	if err := r.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rerun) != 1 || rerun[0] != 111 {
+		t.Errorf("Expected only run 111 to be rerun once, got %v", rerun)
+	}
+}
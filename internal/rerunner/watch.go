@@ -0,0 +1,241 @@
+package rerunner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+const defaultWatchInterval = 15 * time.Second
+
+// watchedRun tracks one triggered rerun. fromAttempt is the attempt that
+// failed; the rerun is done once a newer attempt has completed. The retry
+// fields are only used by --until-green: reruns counts the reruns triggered so
+// far, retryAt is when the next one is due, and gaveUp marks runs that are out
+// of budget or could not be retriggered.
+type watchedRun struct {
+	run         gh.WorkflowRun
+	fromAttempt int
+	err         error
+	shown       string
+
+	reruns     int
+	retryAt    time.Time
+	failedJobs []string
+	gaveUp     bool
+}
+
+func (w *watchedRun) started() bool {
+	return w.run.RunAttempt > w.fromAttempt
+}
+
+func (w *watchedRun) done() bool {
+	return w.started() && w.run.Status == "completed"
+}
+
+func (w *watchedRun) passed() bool {
+	return w.done() && w.run.Conclusion == "success"
+}
+
+// settled reports whether nothing more will happen to the run: its attempt
+// has completed and no retry is scheduled.
+func (w *watchedRun) settled() bool {
+	return w.done() && w.retryAt.IsZero()
+}
+
+// state is the short status shown in the table: "pending" until GitHub has
+// registered the new attempt, then its status and finally its conclusion.
+func (w *watchedRun) state() string {
+	switch {
+	case !w.started():
+		return "pending"
+	case w.run.Status != "completed":
+		return w.run.Status
+	case w.run.Conclusion == "success":
+		return "✓ success"
+	case !w.retryAt.IsZero():
+		return fmt.Sprintf("✗ %s, retry at %s", w.run.Conclusion, w.retryAt.Format("15:04:05"))
+	default:
+		return "✗ " + w.run.Conclusion
+	}
+}
+
+// watch polls the triggered reruns until every new attempt has completed,
+// showing a live status table on a terminal and one line per state change
+// otherwise. With --until-green, failed attempts are retried as they finish
+// (see scheduleRetries) until they pass or run out of budget. --deadline bounds
+// both modes, so an attempt that never shows up or stays queued cannot keep it
+// polling forever. It returns the runs that did not end in success.
+func (r *Rerunner) watch(runs []gh.WorkflowRun) []gh.WorkflowRun {
+	interval := r.opts.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	var deadline time.Time
+	if r.opts.Deadline > 0 {
+		deadline = time.Now().Add(r.opts.Deadline)
+	}
+
+	watched := make([]*watchedRun, len(runs))
+	for i, run := range runs {
+		watched[i] = &watchedRun{run: run, fromAttempt: run.RunAttempt, reruns: 1}
+	}
+	sort.Slice(watched, func(i, j int) bool {
+		if watched[i].run.Name != watched[j].run.Name {
+			return watched[i].run.Name < watched[j].run.Name
+		}
+		return watched[i].run.ID < watched[j].run.ID
+	})
+
+	live := term.FromEnv().IsTerminalOutput()
+	fmt.Printf("\nWatching %d reruns (polling every %s%s)...\n", len(watched), interval, describeDeadline(deadline))
+	if r.opts.UntilGreen {
+		fmt.Printf("Retrying failed jobs until green: up to %d reruns per run, backoff from %s\n",
+			r.retryBudget(), r.retryDelay())
+	}
+
+	drawn := 0
+	for {
+		r.pollWatched(watched)
+		if r.opts.UntilGreen {
+			r.scheduleRetries(watched, time.Now())
+		}
+		if live {
+			drawn = redrawWatchTable(watched, drawn)
+		} else {
+			printWatchChanges(watched)
+		}
+
+		pending := 0
+		for _, w := range watched {
+			if !w.settled() {
+				pending++
+			}
+		}
+		if pending == 0 {
+			break
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			fmt.Printf("Deadline reached; giving up on %d unfinished runs\n", pending)
+			break
+		}
+		time.Sleep(nextPoll(watched, interval, deadline, time.Now()))
+	}
+
+	var red []gh.WorkflowRun
+	for _, w := range watched {
+		if !w.passed() {
+			red = append(red, w.run)
+		}
+	}
+
+	fmt.Printf("Watch summary: %d passed, %d still failing\n", len(watched)-len(red), len(red))
+	for _, w := range watched {
+		if w.passed() {
+			continue
+		}
+		state := w.run.Conclusion
+		if !w.done() {
+			state = "unfinished"
+		}
+		fmt.Printf("  ✗ %s: %s %s\n", describeRun(w.run), state, w.run.HTMLURL)
+		if len(w.failedJobs) > 0 {
+			fmt.Printf("      failing jobs after %d reruns: %s\n", w.reruns, strings.Join(w.failedJobs, ", "))
+		}
+	}
+	return red
+}
+
+// nextPoll is how long to sleep before the next poll: the regular interval,
+// cut short when a retry or the deadline falls due earlier.
+func nextPoll(watched []*watchedRun, interval time.Duration, deadline, now time.Time) time.Duration {
+	wait := interval
+	due := func(t time.Time) {
+		if !t.IsZero() && t.Sub(now) < wait {
+			wait = max(t.Sub(now), 0)
+		}
+	}
+	for _, w := range watched {
+		due(w.retryAt)
+	}
+	due(deadline)
+	return wait
+}
+
+// pollWatched refreshes every unfinished run. A failed fetch keeps the last
+// known state and is retried on the next poll.
+func (r *Rerunner) pollWatched(watched []*watchedRun) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	for _, w := range watched {
+		if w.done() {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(w *watchedRun) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fresh, err := r.client.FetchWorkflowRun(w.run.ID)
+			if err != nil {
+				w.err = fmt.Errorf("could not poll: %w", err)
+				return
+			}
+			w.err = nil
+			w.run = *fresh
+		}(w)
+	}
+	wg.Wait()
+}
+
+// redrawWatchTable rewrites the status table in place, moving the cursor up
+// over the previous rendering. It returns the number of lines drawn.
+func redrawWatchTable(watched []*watchedRun, previous int) int {
+	if previous > 0 {
+		fmt.Printf("\033[%dA\033[J", previous)
+	}
+
+	format := "%-40s | %-20s | %-3s | %-12s | %s\n"
+	fmt.Printf(format, "Workflow", "Branch", "Att", "Status", "URL")
+	fmt.Println(strings.Repeat("-", 100))
+	for _, w := range watched {
+		status := w.state()
+		if w.err != nil {
+			status += " (error)"
+		}
+		fmt.Printf(format,
+			truncate(w.run.Name, 40),
+			truncate(w.run.HeadBranch, 20),
+			fmt.Sprint(w.run.RunAttempt),
+			status,
+			w.run.HTMLURL)
+	}
+	return len(watched) + 2
+}
+
+// printWatchChanges logs each run whose state changed since the last poll,
+// for logs and CI where cursor movement would only add noise.
+func printWatchChanges(watched []*watchedRun) {
+	now := time.Now().Format("15:04:05")
+	for _, w := range watched {
+		if w.err != nil {
+			fmt.Printf("[%s] Warning: %s: %v\n", now, describeRun(w.run), w.err)
+			w.err = nil
+			continue
+		}
+		state := w.state()
+		if state == w.shown {
+			continue
+		}
+		w.shown = state
+		fmt.Printf("[%s] %s: %s\n", now, describeRun(w.run), state)
+	}
+}
@@ -0,0 +1,107 @@
+package rerunner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+func TestRerunner_Run_Watch(t *testing.T) {
+	// Run 1 goes green on its second attempt, run 2 fails again. The first
+	// poll still sees the old attempt, as GitHub does right after a rerun.
+	var mu sync.Mutex
+	polls := map[int64]int{}
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "CI", RunAttempt: 1, Status: "completed", Conclusion: "failure", CreatedAt: time.Now()},
+				{ID: 2, Name: "Lint", RunAttempt: 1, Status: "completed", Conclusion: "failure", CreatedAt: time.Now()},
+			}, nil
+		},
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			polls[runID]++
+			run := &gh.WorkflowRun{ID: runID, RunAttempt: 1, Status: "completed", Conclusion: "failure"}
+			switch {
+			case polls[runID] == 1:
+				// Liveness check before the rerun.
+			case polls[runID] == 2:
+				// Stale: the new attempt is not visible yet.
+			case polls[runID] == 3:
+				run.RunAttempt, run.Status, run.Conclusion = 2, "in_progress", ""
+			case runID == 1:
+				run.RunAttempt, run.Conclusion = 2, "success"
+			default:
+				run.RunAttempt = 2
+			}
+			return run, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{Repo: "owner/repo", Watch: true, WatchInterval: time.Millisecond})
+	err := r.Run()
+	if err == nil {
+		t.Fatal("Expected an error because run 2 is still failing")
+	}
+	if polls[1] != 4 || polls[2] != 4 {
+		t.Errorf("Expected polling to stop once both attempts completed, got %v", polls)
+	}
+}
+
+func TestRerunner_Run_WatchDeadline(t *testing.T) {
+	// The new attempt never shows up, so only the deadline ends the watch.
+	mock := &mockGHClient{
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			if filter.Status != "failure" {
+				return nil, nil
+			}
+			return []gh.WorkflowRun{
+				{ID: 1, Name: "CI", RunAttempt: 1, Status: "completed", Conclusion: "failure", CreatedAt: time.Now()},
+			}, nil
+		},
+		fetchWorkflowRunFunc: func(runID int64) (*gh.WorkflowRun, error) {
+			return &gh.WorkflowRun{ID: runID, RunAttempt: 1, Status: "completed", Conclusion: "failure"}, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			return nil
+		},
+	}
+
+	r := NewRerunner(mock, Options{
+		Repo:          "owner/repo",
+		Watch:         true,
+		WatchInterval: time.Hour,
+		Deadline:      10 * time.Millisecond,
+	})
+	start := time.Now()
+	if err := r.Run(); err == nil {
+		t.Fatal("Expected an error because the rerun never finished")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the deadline to end the watch, took %s", elapsed)
+	}
+}
+
+func TestWatchedRun_State(t *testing.T) {
+	w := &watchedRun{run: gh.WorkflowRun{RunAttempt: 1, Status: "completed", Conclusion: "failure"}, fromAttempt: 1}
+	if w.state() != "pending" || w.done() {
+		t.Errorf("Expected the old attempt to read as pending, got %q", w.state())
+	}
+	w.run.RunAttempt, w.run.Status = 2, "queued"
+	if w.state() != "queued" || w.done() {
+		t.Errorf("Expected queued, got %q", w.state())
+	}
+	w.run.Status, w.run.Conclusion = "completed", "success"
+	if !w.passed() {
+		t.Errorf("Expected the new attempt to pass, got %q", w.state())
+	}
+}
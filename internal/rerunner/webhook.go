@@ -0,0 +1,264 @@
+package rerunner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+// maxWebhookBody caps request bodies; workflow_run payloads are a few dozen KB.
+const maxWebhookBody = 5 << 20
+
+// defaultMaxAttempts caps event-driven reruns when --max-attempts is not set.
+// Every failed rerun delivers a new completed event for its new attempt, so
+// without a cap a run that always fails would be rerun forever.
+const defaultMaxAttempts = 3
+
+// workflowRunEvent is the part of a workflow_run webhook payload we use. The
+// run itself has the same shape as the REST API's, so it decodes straight
+// into gh.WorkflowRun.
+type workflowRunEvent struct {
+	Action      string         `json:"action"`
+	WorkflowRun workflowRunRef `json:"workflow_run"`
+	Repository  gh.RepoRef     `json:"repository"`
+}
+
+type workflowRunRef struct {
+	gh.WorkflowRun
+	PullRequests []struct {
+		Number int `json:"number"`
+	} `json:"pull_requests"`
+}
+
+// webhookDecision is returned as the JSON response body, which shows up in
+// GitHub's delivery log and when replaying payloads by hand.
+type webhookDecision struct {
+	Action string `json:"action"`
+	RunID  int64  `json:"run_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// WebhookHandler serves GitHub webhooks on /webhook and a health check on
+// /healthz. Every delivery must carry a valid X-Hub-Signature-256 for secret.
+// Completed workflow_run events are put through the same rules as the CLI
+// (selected conclusions, workflow/event/actor/branch/job filters, drafts,
+// --max-attempts, superseded and in-flight checks) before RerunWorkflow is
+// called; redeliveries of an attempt that was already rerun are ignored.
+// Without --max-attempts, runs are capped at defaultMaxAttempts.
+func (r *Rerunner) WebhookHandler(secret []byte) (http.Handler, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("a webhook secret is required")
+	}
+	if r.opts.Watch || r.opts.UntilGreen {
+		return nil, errors.New("--watch and --until-green cannot be combined with webhook")
+	}
+	jobFilter, err := r.compileJobFilters()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.resolveActor(); err != nil {
+		return nil, err
+	}
+	if r.opts.MaxAttempts <= 0 {
+		r.opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	handled := &attemptSet{seen: make(map[runAttempt]time.Time)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("POST /webhook", func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+		if !validSignature(secret, body, req.Header.Get("X-Hub-Signature-256")) {
+			logf("webhook: rejected delivery %s with an invalid signature", req.Header.Get("X-GitHub-Delivery"))
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		decision, status := r.handleWebhook(req.Header.Get("X-GitHub-Event"), body, jobFilter, handled)
+		logf("webhook: delivery %s: %s run %d: %s",
+			req.Header.Get("X-GitHub-Delivery"), decision.Action, decision.RunID, decision.Reason)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(decision); err != nil {
+			logf("webhook: delivery %s: could not write response: %v", req.Header.Get("X-GitHub-Delivery"), err)
+		}
+	})
+	return mux, nil
+}
+
+// attemptSet remembers which run attempts the webhook has rerun, so GitHub's
+// redeliveries do not trigger them again. Entries are dropped after
+// retriedRetention, like serve's, so a long-running server does not grow
+// without bound.
+type attemptSet struct {
+	mu   sync.Mutex
+	seen map[runAttempt]time.Time
+}
+
+// claim marks key as handled at now and reports false if it already was.
+func (s *attemptSet) claim(key runAttempt, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, at := range s.seen {
+		if now.Sub(at) > retriedRetention {
+			delete(s.seen, k)
+		}
+	}
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = now
+	return true
+}
+
+func (s *attemptSet) release(key runAttempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+}
+
+// handleWebhook decides what to do with one verified delivery and returns the
+// decision with the HTTP status to answer with.
+func (r *Rerunner) handleWebhook(event string, body []byte, jobFilter jobFilters, handled *attemptSet) (webhookDecision, int) {
+	switch event {
+	case "ping":
+		return webhookDecision{Action: "ignore", Reason: "pong"}, http.StatusOK
+	case "workflow_run":
+	default:
+		return webhookDecision{Action: "ignore", Reason: "unsupported event " + event}, http.StatusAccepted
+	}
+
+	var payload workflowRunEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return webhookDecision{Action: "error", Reason: "invalid payload: " + err.Error()}, http.StatusBadRequest
+	}
+	run := payload.WorkflowRun.WorkflowRun
+	skip := func(reason string) (webhookDecision, int) {
+		return webhookDecision{Action: "skip", RunID: run.ID, Reason: reason}, http.StatusOK
+	}
+
+	if payload.Action != "completed" {
+		return skip("action " + payload.Action)
+	}
+	repo := r.client.Repo()
+	if want := repo.Owner + "/" + repo.Name; !strings.EqualFold(payload.Repository.FullName, want) {
+		return skip(fmt.Sprintf("repository %s is not %s", payload.Repository.FullName, want))
+	}
+	if !slices.Contains(r.statuses(), run.Conclusion) {
+		return skip("conclusion " + run.Conclusion)
+	}
+	if r.opts.Branch != "" && run.HeadBranch != r.opts.Branch {
+		return skip("branch " + run.HeadBranch)
+	}
+	if len(r.filterRuns([]gh.WorkflowRun{run})) == 0 {
+		return skip("filtered out by workflow, event or actor")
+	}
+	if r.opts.MaxAttempts > 0 && run.RunAttempt >= r.opts.MaxAttempts {
+		return skip(fmt.Sprintf("exhausted: attempt cap %d reached", r.opts.MaxAttempts))
+	}
+	if !r.opts.IncludeDrafts {
+		for _, ref := range payload.WorkflowRun.PullRequests {
+			pr, err := r.client.FetchPullRequest(ref.Number)
+			if err != nil {
+				return webhookDecision{Action: "error", RunID: run.ID, Reason: fmt.Sprintf("could not check PR #%d: %v", ref.Number, err)}, http.StatusBadGateway
+			}
+			if pr.IsDraft {
+				return skip(fmt.Sprintf("PR #%d is a draft", pr.Number))
+			}
+		}
+	}
+
+	runs := []gh.WorkflowRun{run}
+	if r.opts.SkipSuperseded {
+		var superseded []skippedRun
+		if runs, superseded = r.skipSuperseded(runs); len(superseded) > 0 {
+			return skip(superseded[0].reason)
+		}
+	}
+	if jobFilter.active() {
+		var skipped []skippedRun
+		if runs, skipped = filterRunsByJobs(runs, r.fetchFailedJobs(runs), jobFilter); len(skipped) > 0 {
+			return skip(skipped[0].reason)
+		}
+	}
+	if reason, busy := r.inFlight(run, r.fetchActiveRuns(runs)); busy {
+		return skip(reason)
+	}
+
+	if r.opts.DryRun {
+		return webhookDecision{Action: "would-rerun", RunID: run.ID, Reason: describeRun(run)}, http.StatusOK
+	}
+	key := runAttempt{ID: run.ID, Attempt: run.RunAttempt}
+	if !handled.claim(key, time.Now()) {
+		return skip(fmt.Sprintf("attempt %d was already rerun", run.RunAttempt))
+	}
+	if err := r.client.RerunWorkflow(run.ID, r.opts.FailedOnly); err != nil {
+		handled.release(key)
+		return webhookDecision{Action: "error", RunID: run.ID, Reason: err.Error()}, http.StatusBadGateway
+	}
+	return webhookDecision{Action: "rerun", RunID: run.ID, Reason: describeRun(run)}, http.StatusOK
+}
+
+// validSignature checks GitHub's "sha256=<hex HMAC of the body>" header in
+// constant time.
+func validSignature(secret, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ListenWebhook serves WebhookHandler on addr until ctx is cancelled, then
+// gives in-flight deliveries a few seconds to finish.
+func (r *Rerunner) ListenWebhook(ctx context.Context, addr string, secret []byte) error {
+	handler, err := r.WebhookHandler(secret)
+	if err != nil {
+		return err
+	}
+	repo := r.client.Repo()
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	logf("webhook: listening on %s for workflow_run events of %s/%s, up to attempt %d (POST /webhook, GET /healthz)",
+		addr, repo.Owner, repo.Name, r.opts.MaxAttempts)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logf("webhook: shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
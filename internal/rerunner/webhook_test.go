@@ -0,0 +1,199 @@
+package rerunner
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
+)
+
+const testSecret = "s3cret"
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver replays a payload against the handler the way GitHub would.
+func deliver(t *testing.T, h http.Handler, event string, body []byte, signature string) (int, webhookDecision) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", "test-delivery")
+	req.Header.Set("X-Hub-Signature-256", signature)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var decision webhookDecision
+	if rec.Code != http.StatusUnauthorized {
+		if err := json.Unmarshal(rec.Body.Bytes(), &decision); err != nil {
+			t.Fatalf("Invalid response body %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, decision
+}
+
+// payload loads the recorded delivery and applies edits to its workflow_run.
+func payload(t *testing.T, edit func(run map[string]any)) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/workflow_run_failure.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit == nil {
+		return data
+	}
+	var event map[string]any
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+	edit(event["workflow_run"].(map[string]any))
+	data, err = json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func webhookClient(draft bool, reruns *[]int64) *mockGHClient {
+	return &mockGHClient{
+		fetchPullRequestFunc: func(number int) (*gh.PullRequest, error) {
+			return &gh.PullRequest{Number: number, IsDraft: draft}, nil
+		},
+		fetchWorkflowRunsFunc: func(filter gh.RunFilter) ([]gh.WorkflowRun, error) {
+			return nil, nil
+		},
+		rerunWorkflowFunc: func(runID int64, failedOnly bool) error {
+			*reruns = append(*reruns, runID)
+			return nil
+		},
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var reruns []int64
+	r := NewRerunner(webhookClient(false, &reruns), Options{MaxAttempts: 3, FailedOnly: true})
+	h, err := r.WebhookHandler([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := payload(t, nil)
+	if code, _ := deliver(t, h, "workflow_run", body, "sha256=deadbeef"); code != http.StatusUnauthorized {
+		t.Errorf("Expected a bad signature to be rejected, got %d", code)
+	}
+
+	code, decision := deliver(t, h, "workflow_run", body, sign(body))
+	if code != http.StatusOK || decision.Action != "rerun" || decision.RunID != 9876543210 {
+		t.Errorf("Expected the failed run to be rerun, got %d %+v", code, decision)
+	}
+
+	// GitHub redelivers on timeouts; the same attempt must not be rerun twice.
+	if _, decision := deliver(t, h, "workflow_run", body, sign(body)); decision.Action != "skip" {
+		t.Errorf("Expected a redelivery to be skipped, got %+v", decision)
+	}
+	if len(reruns) != 1 {
+		t.Errorf("Expected exactly one rerun, got %v", reruns)
+	}
+
+	skips := map[string]func(run map[string]any){
+		"conclusion success": func(run map[string]any) { run["conclusion"] = "success" },
+		"conclusion cancelled": func(run map[string]any) {
+			run["conclusion"] = "cancelled"
+		},
+		"exhausted": func(run map[string]any) { run["run_attempt"] = 3 },
+	}
+	for want, edit := range skips {
+		body := payload(t, edit)
+		if _, decision := deliver(t, h, "workflow_run", body, sign(body)); decision.Action != "skip" || !strings.HasPrefix(decision.Reason, want) {
+			t.Errorf("Expected skip %q, got %+v", want, decision)
+		}
+	}
+
+	if code, decision := deliver(t, h, "push", []byte("{}"), sign([]byte("{}"))); code != http.StatusAccepted || decision.Action != "ignore" {
+		t.Errorf("Expected other events to be ignored, got %d %+v", code, decision)
+	}
+}
+
+func TestWebhookHandler_Draft(t *testing.T) {
+	var reruns []int64
+	r := NewRerunner(webhookClient(true, &reruns), Options{})
+	h, err := r.WebhookHandler([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := payload(t, nil)
+	if _, decision := deliver(t, h, "workflow_run", body, sign(body)); decision.Reason != "PR #42 is a draft" {
+		t.Errorf("Expected the draft PR's run to be skipped, got %+v", decision)
+	}
+	if len(reruns) != 0 {
+		t.Errorf("Expected no reruns, got %v", reruns)
+	}
+}
+
+func TestWebhookHandler_DefaultAttemptCap(t *testing.T) {
+	var reruns []int64
+	r := NewRerunner(webhookClient(false, &reruns), Options{})
+	h, err := r.WebhookHandler([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each failed rerun arrives as a new attempt; the third is the last.
+	for attempt := 1; attempt <= defaultMaxAttempts; attempt++ {
+		body := payload(t, func(run map[string]any) { run["run_attempt"] = attempt })
+		_, decision := deliver(t, h, "workflow_run", body, sign(body))
+		if want := attempt < defaultMaxAttempts; (decision.Action == "rerun") != want {
+			t.Errorf("attempt %d: expected rerun %v, got %+v", attempt, want, decision)
+		}
+	}
+	if len(reruns) != defaultMaxAttempts-1 {
+		t.Errorf("Expected %d reruns before the cap, got %v", defaultMaxAttempts-1, reruns)
+	}
+}
+
+func TestWebhookHandler_Health(t *testing.T) {
+	r := NewRerunner(&mockGHClient{}, Options{})
+	h, err := r.WebhookHandler([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz to return 200, got %d", rec.Code)
+	}
+
+	if _, err := r.WebhookHandler(nil); err == nil {
+		t.Error("Expected an empty secret to be refused")
+	}
+}
+
+func TestAttemptSet_Claim(t *testing.T) {
+	s := &attemptSet{seen: make(map[runAttempt]time.Time)}
+	now := time.Now()
+	old := runAttempt{ID: 1, Attempt: 1}
+	if !s.claim(old, now.Add(-retriedRetention-time.Hour)) {
+		t.Fatal("Expected the first claim to succeed")
+	}
+	if s.claim(old, now.Add(-retriedRetention)) {
+		t.Error("Expected a redelivery to be refused")
+	}
+	if !s.claim(runAttempt{ID: 2, Attempt: 1}, now) {
+		t.Error("Expected a new attempt to be claimed")
+	}
+	if _, ok := s.seen[old]; ok {
+		t.Errorf("Expected attempts older than %s to be forgotten, got %v", retriedRetention, s.seen)
+	}
+}
@@ -0,0 +1,149 @@
+package rerunner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the absolute forms accepted by --since/--until. Layouts
+// without a zone are interpreted in the local time zone.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// durationRe matches durations built from Go units plus d (day) and w (week),
+// e.g. 3d, 2w, 1d12h or 90m.
+var (
+	durationRe     = regexp.MustCompile(`^(?:[0-9]+(?:\.[0-9]+)?(?:ns|us|µs|ms|s|m|h|d|w))+$`)
+	durationPartRe = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)(ns|us|µs|ms|s|m|h|d|w)`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseTimeBound turns a --since/--until value into a point in time relative
+// to now. It accepts:
+//
+//   - durations ago: 90m, 24h, 3d, 2w, 1d12h
+//   - calendar words: now, today, yesterday, last-monday (or "last monday")
+//   - absolute times: 2006-01-02, 2006-01-02 15:04, 2006-01-02T15:04:05Z
+//
+// Calendar words and zone-less timestamps are interpreted in now's location,
+// which is the local time zone outside of tests.
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	expr := strings.ToLower(strings.TrimSpace(s))
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("empty time expression")
+	}
+
+	if durationRe.MatchString(expr) {
+		d, err := parseDuration(expr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		return now.Add(-d), nil
+	}
+
+	loc := now.Location()
+	midnight := func(days int) time.Time {
+		y, m, d := now.Date()
+		return time.Date(y, m, d+days, 0, 0, 0, 0, loc)
+	}
+	switch expr {
+	case "now":
+		return now, nil
+	case "today":
+		return midnight(0), nil
+	case "yesterday":
+		return midnight(-1), nil
+	}
+	if day, ok := strings.CutPrefix(strings.ReplaceAll(expr, " ", "-"), "last-"); ok {
+		wd, ok := weekdays[day]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid weekday %q in %q", day, s)
+		}
+		// The most recent such day strictly before today.
+		back := (int(now.Weekday())-int(wd)+6)%7 + 1
+		return midnight(-back), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a duration (3d, 24h), a word (today, yesterday, last-monday) or a date (2006-01-02, 2006-01-02T15:04:05Z)", s)
+}
+
+// parseDuration extends time.ParseDuration with d (24h) and w (7d) units.
+// Days are fixed 24h spans; use a date or calendar word for midnight bounds.
+func parseDuration(expr string) (time.Duration, error) {
+	var total time.Duration
+	for _, part := range durationPartRe.FindAllStringSubmatch(expr, -1) {
+		var unit time.Duration
+		switch part[2] {
+		case "d":
+			unit = 24 * time.Hour
+		case "w":
+			unit = 7 * 24 * time.Hour
+		default:
+			d, err := time.ParseDuration(part[0])
+			if err != nil {
+				return 0, err
+			}
+			total += d
+			continue
+		}
+		n, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	return total, nil
+}
+
+// resolveWindow computes the created-at window for this run from --since and
+// --until. It is evaluated once per Run so every query shares the same bounds.
+func (r *Rerunner) resolveWindow(now time.Time) error {
+	r.since, r.until = time.Time{}, time.Time{}
+	r.settledAfter = time.Time{}
+	if r.settledWindow > 0 {
+		r.settledAfter = now.Add(-r.settledWindow)
+	}
+
+	if r.opts.Since != "" {
+		t, err := parseTimeBound(r.opts.Since, now)
+		if err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+		r.since = t
+	}
+	if r.opts.Until != "" {
+		t, err := parseTimeBound(r.opts.Until, now)
+		if err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+		r.until = t
+	}
+	if !r.since.IsZero() && !r.until.IsZero() && !r.since.Before(r.until) {
+		return fmt.Errorf("--since (%s) must be before --until (%s)",
+			r.since.Format(time.RFC3339), r.until.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package rerunner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	// Friday, 10 May 2024.
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, loc)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"24h", now.Add(-24 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"3d", now.Add(-72 * time.Hour)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+		{"1d12h", now.Add(-36 * time.Hour)},
+		{"1.5d", now.Add(-36 * time.Hour)},
+		{"now", now},
+		{"today", time.Date(2024, 5, 10, 0, 0, 0, 0, loc)},
+		{"Yesterday", time.Date(2024, 5, 9, 0, 0, 0, 0, loc)},
+		{"last-monday", time.Date(2024, 5, 6, 0, 0, 0, 0, loc)},
+		{"last friday", time.Date(2024, 5, 3, 0, 0, 0, 0, loc)},
+		{"last-saturday", time.Date(2024, 5, 4, 0, 0, 0, 0, loc)},
+		{"2024-05-01T08:30:00Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{"2024-05-01 08:30", time.Date(2024, 5, 1, 8, 30, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTimeBound(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "last week", "3 days", "tomorrowish", "2024-13-01"} {
+		if _, err := parseTimeBound(bad, now); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestResolveWindow(t *testing.T) {
+	now := time.Now()
+	r := NewRerunner(&mockGHClient{}, Options{Since: "48h", Until: "24h"})
+	if err := r.resolveWindow(now); err != nil {
+		t.Fatalf("resolveWindow: %v", err)
+	}
+	filters := r.runFilters("")
+	if !filters[0].CreatedAfter.Equal(now.Add(-48*time.Hour)) || !filters[0].CreatedBefore.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("Expected the window to be passed to the server, got %+v", filters[0])
+	}
+
+	r = NewRerunner(&mockGHClient{}, Options{Since: "24h", Until: "48h"})
+	if err := r.resolveWindow(now); err == nil {
+		t.Error("Expected an error when --since is after --until")
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 	"time"
 
 	"github.com/corneliusroemer/gh-rerun-failed/internal/gh"
@@ -15,36 +19,138 @@ var (
 	branch           string
 	limit            int
 	sinceStr         string
+	untilStr         string
 	prNumber         int
+	commit           string
+	readStdin        bool
+	commitRange      string
 	allOpenPRs       bool
+	allBranches      bool
+	watch            bool
+	watchInterval    time.Duration
+	untilGreen       bool
+	retries          int
+	retryDelay       time.Duration
+	deadline         time.Duration
+	interval         time.Duration
+	stateFile        string
+	listenAddr       string
+	webhookSecret    string
+	fromEvent        bool
 	dryRun           bool
 	failedOnly       bool
 	includeDrafts    bool
 	includeCancelled bool
 	includeTimedOut  bool
+	workflows        []string
+	excludeWorkflows []string
+	jobs             []string
+	excludeJobs      []string
+	skipSuperseded   bool
+	maxAttempts      int
+	event            string
+	actor            string
+	mine             bool
+	labels           []string
+	baseBranch       string
+	author           string
+	reviewDecision   string
 )
 
 func main() {
 	rootCmd := &cobra.Command{
-		Use:   "gh-rerun-failed",
+		Use:   "gh-rerun-failed [<pr> | <sha> | <url>]...",
 		Short: "Rerun failed GitHub Actions runs with ease",
-		Long:  `A GitHub CLI extension to rerun failed workflow runs across branches, commits, and PRs.`,
+		Long: `A GitHub CLI extension to rerun failed workflow runs across branches, commits, and PRs.
+
+Positional arguments select what to rerun and may be mixed freely: PR numbers,
+commit SHAs or refs, and pull request, workflow run or commit URLs. Pass "-"
+to read run IDs from stdin, e.g. gh run list --json databaseId | gh rerun-failed -`,
+		// Targets are positional, so unknown words must not be taken for
+		// subcommands.
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRerunner()
+			// Past flag parsing, errors (e.g. --watch finding red runs) are
+			// not usage mistakes.
+			cmd.SilenceUsage = true
+			return runRerunner(args)
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&repoOverride, "repo", "R", "", "Select another repository using the [HOST/]OWNER/REPO format")
-	rootCmd.Flags().StringVarP(&branch, "branch", "b", "", "Filter runs by branch")
-	rootCmd.Flags().IntVarP(&limit, "limit", "L", 0, "Limit the number of runs to process")
-	rootCmd.Flags().StringVarP(&sinceStr, "since", "s", "", "Only process runs since this duration (e.g. 24h, 1h)")
-	rootCmd.Flags().IntVar(&prNumber, "pr", 0, "Filter runs by PR number")
-	rootCmd.Flags().BoolVar(&allOpenPRs, "all-prs", false, "Process runs for all open PRs")
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without performing re-runs")
-	rootCmd.Flags().BoolVar(&failedOnly, "failed-only", true, "Only rerun failed jobs within a run")
-	rootCmd.Flags().BoolVar(&includeDrafts, "include-drafts", false, "Include draft PRs when using --all-prs")
-	rootCmd.Flags().BoolVar(&includeCancelled, "include-cancelled", false, "Include cancelled runs")
-	rootCmd.Flags().BoolVar(&includeTimedOut, "include-timed-out", false, "Include timed-out runs")
+	rootCmd.PersistentFlags().StringVarP(&repoOverride, "repo", "R", "", "Select another repository using the [HOST/]OWNER/REPO format")
+	rootCmd.PersistentFlags().StringVarP(&branch, "branch", "b", "", "Filter runs by branch (defaults to the current branch's upstream)")
+	rootCmd.PersistentFlags().BoolVar(&allBranches, "all-branches", false, "Scan runs on every branch instead of defaulting to the current one")
+	rootCmd.PersistentFlags().IntVarP(&limit, "limit", "L", 0, "Limit the number of runs to process")
+	rootCmd.PersistentFlags().StringVarP(&sinceStr, "since", "s", "", "Only process runs created since this time (e.g. 24h, 3d, 2w, today, yesterday, last-monday, 2006-01-02)")
+	rootCmd.PersistentFlags().StringVar(&untilStr, "until", "", "Only process runs created before this duration ago or time (same formats as --since)")
+	rootCmd.PersistentFlags().IntVar(&prNumber, "pr", 0, "Filter runs by PR number")
+	rootCmd.PersistentFlags().StringVarP(&commit, "commit", "c", "", "Filter runs by commit (full or short SHA, or a ref like HEAD~3)")
+	rootCmd.PersistentFlags().StringVar(&commitRange, "range", "", "Process runs for every commit in a range A..B (e.g. v1.2.0..main)")
+	rootCmd.PersistentFlags().BoolVar(&readStdin, "stdin", false, "Read run IDs, run URLs or JSON objects with an id/databaseId field from stdin (same as passing -)")
+	rootCmd.PersistentFlags().BoolVar(&allOpenPRs, "all-prs", false, "Process runs for all open PRs")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without performing re-runs")
+	rootCmd.PersistentFlags().BoolVar(&failedOnly, "failed-only", true, "Only rerun failed jobs within a run")
+	rootCmd.PersistentFlags().StringVarP(&event, "event", "e", "", "Filter runs by triggering event (push, pull_request, schedule, merge_group, workflow_dispatch, ...)")
+	rootCmd.PersistentFlags().StringVar(&actor, "actor", "", "Only process runs triggered by this user and, with --all-prs, PRs authored by them")
+	rootCmd.PersistentFlags().BoolVar(&mine, "mine", false, "Like --actor, using the authenticated user")
+	rootCmd.PersistentFlags().BoolVar(&includeDrafts, "include-drafts", false, "Include draft PRs when using --all-prs")
+	rootCmd.PersistentFlags().StringArrayVar(&labels, "label", nil, "With --all-prs, only PRs carrying this label (repeatable; all must match)")
+	rootCmd.PersistentFlags().StringVar(&baseBranch, "base", "", "With --all-prs, only PRs into this base branch (globs allowed, e.g. release/*)")
+	rootCmd.PersistentFlags().StringVar(&author, "author", "", "With --all-prs, only PRs authored by this user")
+	rootCmd.PersistentFlags().StringVar(&reviewDecision, "review", "", "With --all-prs, only PRs with this review state: approved, changes-requested or review-required")
+	rootCmd.PersistentFlags().BoolVar(&includeCancelled, "include-cancelled", false, "Include cancelled runs")
+	rootCmd.PersistentFlags().BoolVar(&includeTimedOut, "include-timed-out", false, "Include timed-out runs")
+	rootCmd.PersistentFlags().StringArrayVarP(&workflows, "workflow", "w", nil, "Only process runs of this workflow (name, ID or .github/workflows/x.yml path; globs allowed; repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&excludeWorkflows, "exclude-workflow", nil, "Never process runs of this workflow (name, ID or path; globs allowed; repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&jobs, "job", nil, "Only process runs where a failed job matches this glob or /regexp/ (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&excludeJobs, "exclude-job", nil, "Skip runs whose failed jobs all match this glob or /regexp/ (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&skipSuperseded, "skip-superseded", false, "Skip failures whose workflow has since succeeded on the same branch")
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", 0, "Skip runs whose attempt number has reached this cap and report them as exhausted")
+	rootCmd.Flags().BoolVar(&fromEvent, "from-event", false, "Inside GitHub Actions, infer repo and runs from GITHUB_EVENT_PATH and write the report to the job summary and step outputs")
+	rootCmd.PersistentFlags().BoolVar(&watch, "watch", false, "Follow triggered reruns until they complete; exit nonzero if any is still failing")
+	rootCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", 15*time.Second, "How often --watch polls run status")
+	rootCmd.PersistentFlags().BoolVar(&untilGreen, "until-green", false, "Watch reruns and keep retrying their failed jobs until they pass (implies --watch)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "With --until-green, maximum reruns per run, including the first")
+	rootCmd.PersistentFlags().DurationVar(&retryDelay, "retry-delay", 2*time.Minute, "With --until-green, wait before the first retry; doubles on each further retry")
+	rootCmd.PersistentFlags().DurationVar(&deadline, "deadline", 0, "With --watch or --until-green, stop watching and retrying after this long (e.g. 1h)")
+
+	rootCmd.MarkFlagsMutuallyExclusive("branch", "all-branches")
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Keep rerunning failed runs on an interval until stopped",
+		Long: `Run discovery every --interval with a sliding --since window (unless set,
+runs that finished within twice the interval) and rerun what fails, logging
+every decision. Attempts
+that were already rerun are remembered, optionally across restarts via
+--state-file, so nothing is triggered twice. SIGINT or SIGTERM stop the
+daemon after the current cycle.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return runServe()
+		},
+	}
+	serveCmd.Flags().DurationVar(&interval, "interval", 10*time.Minute, "Time between discovery cycles")
+	serveCmd.Flags().StringVar(&stateFile, "state-file", "", "Persist retried run attempts to this JSON file across restarts")
+	rootCmd.AddCommand(serveCmd)
+
+	webhookCmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Rerun failed runs as GitHub reports them via workflow_run webhooks",
+		Long: `Listen for GitHub workflow_run webhooks on POST /webhook and rerun completed
+runs that match the usual filters. Deliveries must be signed with the webhook
+secret (X-Hub-Signature-256). GET /healthz reports liveness. SIGINT or SIGTERM
+shut the server down gracefully.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return runWebhook()
+		},
+	}
+	webhookCmd.Flags().StringVar(&listenAddr, "addr", ":8080", "Address to listen on")
+	webhookCmd.Flags().StringVar(&webhookSecret, "secret", "", "Webhook secret (default $RERUN_WEBHOOK_SECRET)")
+	rootCmd.AddCommand(webhookCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -52,14 +158,12 @@ func main() {
 	}
 }
 
-func runRerunner() error {
-	var since time.Duration
-	if sinceStr != "" {
-		var err error
-		since, err = time.ParseDuration(sinceStr)
-		if err != nil {
-			return fmt.Errorf("invalid duration for --since: %w", err)
-		}
+func runRerunner(args []string) error {
+	if readStdin && !slices.Contains(args, "-") {
+		args = append(args, "-")
+	}
+	if fromEvent {
+		return runFromEvent(args)
 	}
 
 	client, err := gh.NewClient(repoOverride)
@@ -67,20 +171,104 @@ func runRerunner() error {
 		return err
 	}
 
-	opts := rerunner.Options{
+	r := rerunner.NewRerunner(client, newOptions(args))
+	return r.Run()
+}
+
+func runFromEvent(args []string) error {
+	ev, err := rerunner.ReadActionsEvent(os.Getenv)
+	if err != nil {
+		return err
+	}
+	opts := newOptions(args)
+	ev.Apply(&opts)
+
+	// go-gh picks up GITHUB_TOKEN/GH_TOKEN from the environment.
+	client, err := gh.NewClient(opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	r := rerunner.NewRerunner(client, opts)
+	return r.RunForEvent(ev, os.Getenv)
+}
+
+func runServe() error {
+	client, err := gh.NewClient(repoOverride)
+	if err != nil {
+		return err
+	}
+
+	opts := newOptions(nil)
+	opts.Interval = interval
+	opts.StateFile = stateFile
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := rerunner.NewRerunner(client, opts)
+	return r.Serve(ctx)
+}
+
+func runWebhook() error {
+	secret := webhookSecret
+	if secret == "" {
+		secret = os.Getenv("RERUN_WEBHOOK_SECRET")
+	}
+	if secret == "" {
+		return fmt.Errorf("a webhook secret is required: pass --secret or set RERUN_WEBHOOK_SECRET")
+	}
+
+	client, err := gh.NewClient(repoOverride)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := rerunner.NewRerunner(client, newOptions(nil))
+	return r.ListenWebhook(ctx, listenAddr, []byte(secret))
+}
+
+// newOptions builds rerunner options from the shared flags.
+func newOptions(args []string) rerunner.Options {
+	return rerunner.Options{
 		Repo:             repoOverride,
 		Branch:           branch,
 		Limit:            limit,
-		Since:            since,
+		Since:            sinceStr,
+		Until:            untilStr,
 		PRNumber:         prNumber,
+		Commit:           commit,
+		Range:            commitRange,
+		Targets:          args,
+		Stdin:            os.Stdin,
 		AllOpenPRs:       allOpenPRs,
+		AllBranches:      allBranches,
+		Watch:            watch,
+		WatchInterval:    watchInterval,
+		UntilGreen:       untilGreen,
+		Retries:          retries,
+		RetryDelay:       retryDelay,
+		Deadline:         deadline,
 		DryRun:           dryRun,
 		FailedOnly:       failedOnly,
 		IncludeDrafts:    includeDrafts,
 		IncludeCancelled: includeCancelled,
 		IncludeTimedOut:  includeTimedOut,
+		Workflows:        workflows,
+		ExcludeWorkflows: excludeWorkflows,
+		Jobs:             jobs,
+		ExcludeJobs:      excludeJobs,
+		SkipSuperseded:   skipSuperseded,
+		MaxAttempts:      maxAttempts,
+		Event:            event,
+		Actor:            actor,
+		Mine:             mine,
+		Labels:           labels,
+		BaseBranch:       baseBranch,
+		Author:           author,
+		ReviewDecision:   reviewDecision,
 	}
-
-	r := rerunner.NewRerunner(client, opts)
-	return r.Run()
 }